@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+func diff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+
+	files, err := parseArgs(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(files) != 2 {
+		return errors.New("diff expects an old and a new file")
+	}
+
+	oldDoc, err := load(files[0])
+
+	if err != nil {
+		return err
+	}
+
+	newDoc, err := load(files[1])
+
+	if err != nil {
+		return err
+	}
+
+	d, err := identitydsl.Compare(oldDoc, newDoc)
+
+	if err != nil {
+		return err
+	}
+
+	if d.Empty() {
+		fmt.Println("No change in access")
+		return nil
+	}
+
+	for _, a := range d.Added {
+		fmt.Printf("+ %s %s %s %s\n", a.Account.ID, a.PrincipalType(), a.PrincipalName(), a.Role.Name)
+	}
+
+	for _, a := range d.Removed {
+		fmt.Printf("- %s %s %s %s\n", a.Account.ID, a.PrincipalType(), a.PrincipalName(), a.Role.Name)
+	}
+
+	for _, r := range d.Roles {
+		fmt.Printf("~ Role %s\n", r.Name)
+
+		for _, p := range r.Added {
+			fmt.Printf("    + %s\n", p)
+		}
+
+		for _, p := range r.Removed {
+			fmt.Printf("    - %s\n", p)
+		}
+
+		for _, p := range r.Properties {
+			if p.New != "" {
+				fmt.Printf("    + %s %s\n", p.Name, p.New)
+			}
+
+			if p.Old != "" {
+				fmt.Printf("    - %s %s\n", p.Name, p.Old)
+			}
+		}
+	}
+
+	fmt.Printf("\n%s added, %d removed, %s changed\n", count(len(d.Added), "assignment"), len(d.Removed), count(len(d.Roles), "role"))
+
+	return nil
+}
+
+// count writes a number of things, in the plural unless there is one.
+func count(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
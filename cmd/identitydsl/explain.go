@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+func explain(args []string) error {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+
+	var filter identitydsl.Filter
+
+	flags.StringVar(&filter.Account, "account", "", "only explain assignments in the account with this ID")
+	flags.StringVar(&filter.User, "user", "", "only explain assignments to the user with this name, or a group they are a member of")
+	flags.StringVar(&filter.Group, "group", "", "only explain assignments to the group with this name")
+	flags.StringVar(&filter.Role, "role", "", "only explain assignments of the role with this name")
+
+	files, err := parseArgs(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(files) != 1 {
+		return errors.New("explain expects a single file or directory")
+	}
+
+	if filter.User != "" && filter.Group != "" {
+		return errors.New("explain expects either -user or -group, not both")
+	}
+
+	doc, err := load(files[0])
+
+	if err != nil {
+		return err
+	}
+
+	assignments, err := identitydsl.Expand(doc)
+
+	if err != nil {
+		return err
+	}
+
+	assignments = filter.Apply(assignments)
+
+	if len(assignments) == 0 {
+		return errors.New("no matching assignments")
+	}
+
+	for i, a := range assignments {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		printExplanation(files[0], a, filter.User)
+	}
+
+	return nil
+}
+
+// printExplanation lists each Assign block making an assignment, with the
+// contexts around it and the selectors picking each part of the assignment.
+// When the assignment is to a group the user is a member of, the Members
+// selectors picking the user are listed first. Lines in files other than the
+// one given are described with their file.
+func printExplanation(path string, a identitydsl.Assignment, user string) {
+	principal := identitydsl.KindGroup
+
+	if a.User != nil {
+		principal = identitydsl.KindUser
+	}
+
+	fmt.Printf("%s %s has %s in account %s\n", principal, a.PrincipalName(), a.Role.Name, a.Account.ID)
+
+	if a.Group != nil && user != "" {
+		fmt.Printf("  User %s is a member\n", user)
+
+		for _, s := range a.Group.Member(user).Selectors {
+			fmt.Printf("    Members %s %s\n", s, at(path, s.Start()))
+		}
+	}
+
+	for _, source := range a.Sources {
+		fmt.Printf("  Assign %s\n", at(path, source.Assign.Pos()))
+
+		for _, c := range source.Contexts {
+			fmt.Printf("    in %ss %s %s\n", c.Kind, selectors(c.Selectors), at(path, c.Pos()))
+		}
+
+		for _, m := range source.Matches {
+			fmt.Printf("    %s %s %s\n", m.Kind, m.Selector, at(path, m.Selector.Start()))
+		}
+	}
+}
+
+// at describes the line at a position, along with its file when that isn't
+// the path given.
+func at(path string, pos identitydsl.Position) string {
+	if pos.File != "" && pos.File != filepath.Clean(path) {
+		return fmt.Sprintf("on line %d of %s", pos.Line, pos.File)
+	}
+
+	return fmt.Sprintf("on line %d", pos.Line)
+}
+
+// selectors writes a list of selectors as they would appear in a document.
+func selectors(list []identitydsl.Selector) string {
+	texts := make([]string, len(list))
+
+	for i, s := range list {
+		texts[i] = s.String()
+	}
+
+	return strings.Join(texts, ", ")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+func format(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+
+	check := flags.Bool("check", false, "list files which are not formatted rather than rewriting them")
+
+	files, err := parseArgs(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return errors.New("fmt expects at least one file")
+	}
+
+	var unformatted int
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return err
+		}
+
+		doc, err := identitydsl.Parse(string(data))
+
+		if err := report(file, err); err != nil {
+			return err
+		}
+
+		formatted := identitydsl.Format(doc)
+
+		if bytes.Equal(data, formatted) {
+			continue
+		}
+
+		if *check {
+			fmt.Println(file)
+			unformatted++
+			continue
+		}
+
+		if err := os.WriteFile(file, formatted, 0644); err != nil {
+			return err
+		}
+	}
+
+	if unformatted == 1 {
+		return errors.New("1 file is not formatted")
+	}
+
+	if unformatted > 1 {
+		return fmt.Errorf("%d files are not formatted", unformatted)
+	}
+
+	return nil
+}
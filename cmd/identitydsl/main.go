@@ -1,22 +1,127 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+	"github.com/xdesign-jheather/identitydsl/pkg/lsp"
 )
 
+const usage = `Usage: identitydsl <command> [arguments]
+
+Commands:
+  validate <path>    check the file or directory for syntax and logical errors
+  synth <path>       synthesize IaC for the file or directory in the working
+                     directory
+  explain <path>     show why assignments are made, filtered with -account,
+                     -user, -group or -role
+  query <path>       list assignments, filtered with -account, -user, -group,
+                     -role, -tag or -label
+  diff <old> <new>   show the access added and removed between two versions
+  fmt <file>...      rewrite files in the canonical format, or with -check
+                     list those which are not
+  lsp                run a language server for editors over stdio
+`
+
 func main() {
+	log.SetFlags(0)
+
 	if len(os.Args) < 2 {
-		return
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
 	}
 
-	data, err := os.ReadFile(os.Args[1])
+	var err error
+
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "validate":
+		err = validate(args)
+	case "synth":
+		err = synth(args)
+	case "explain":
+		err = explain(args)
+	case "query":
+		err = query(args)
+	case "diff":
+		err = diff(args)
+	case "fmt":
+		err = format(args)
+	case "lsp":
+		err = lsp.Serve(os.Stdin, os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %s\n\n%s", command, usage)
+		os.Exit(2)
+	}
 
 	if err != nil {
 		log.Fatal(err)
 	}
+}
+
+// parseArgs parses flags which may be given before or after the positional
+// arguments, so "synth ic.txt -format=json" works as the README shows.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// load reads, parses and validates a project, either a file and those it
+// includes or a directory, listing every problem found on stderr before
+// returning an error summarising them.
+func load(path string) (*identitydsl.Document, error) {
+	doc, err := identitydsl.Load(path)
+
+	if err == nil {
+		err = identitydsl.Validate(doc)
+	}
+
+	if err := report(path, err); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// report lists every problem in the errors found in a file or project on
+// stderr, naming the file each was found in, and returns an error
+// summarising them.
+func report(file string, err error) error {
+	errs, ok := err.(identitydsl.Errors)
+
+	if !ok {
+		return err
+	}
+
+	for _, e := range errs {
+		name := file
+
+		if e.Pos.File != "" {
+			name = e.Pos.File
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, e)
+	}
+
+	if len(errs) == 1 {
+		return fmt.Errorf("%s: 1 problem found", file)
+	}
 
-	identitydsl.Check(string(data))
+	return fmt.Errorf("%s: %d problems found", file, len(errs))
 }
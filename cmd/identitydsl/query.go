@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+func query(args []string) error {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+
+	var filter identitydsl.Filter
+
+	flags.StringVar(&filter.Account, "account", "", "only list assignments in the account with this ID")
+	flags.StringVar(&filter.User, "user", "", "only list assignments to the user with this name, or a group they are a member of")
+	flags.StringVar(&filter.Group, "group", "", "only list assignments to the group with this name")
+	flags.StringVar(&filter.Role, "role", "", "only list assignments of the role with this name")
+	flags.StringVar(&filter.OU, "ou", "", "only list assignments in accounts within the OU with this name, or an OU beneath it")
+
+	flags.Func("tag", "only list assignments where the account, user or group has the tag `Key=Value`, may be repeated", func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+
+		if !ok {
+			return errors.New("expected Key=Value")
+		}
+
+		filter.Tags = append(filter.Tags, identitydsl.Tag{Key: key, Value: value})
+
+		return nil
+	})
+
+	flags.Func("label", "only list assignments where the account, user or group has the label, may be repeated", func(s string) error {
+		filter.Labels = append(filter.Labels, identitydsl.Label(s))
+		return nil
+	})
+
+	format := flags.String("format", "table", "output format, table, csv or json")
+
+	files, err := parseArgs(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(files) != 1 {
+		return errors.New("query expects a single file or directory")
+	}
+
+	if filter.User != "" && filter.Group != "" {
+		return errors.New("query expects either -user or -group, not both")
+	}
+
+	write, ok := queryFormats[*format]
+
+	if !ok {
+		return fmt.Errorf("unsupported format %s", *format)
+	}
+
+	doc, err := load(files[0])
+
+	if err != nil {
+		return err
+	}
+
+	assignments, err := identitydsl.Expand(doc)
+
+	if err != nil {
+		return err
+	}
+
+	return write(filter.Apply(assignments))
+}
+
+// queryFormats write assignments to stdout in each format query supports.
+var queryFormats = map[string]func([]identitydsl.Assignment) error{
+	"table": writeTable,
+	"csv":   writeCSV,
+	"json":  writeJSON,
+}
+
+// queryColumns head the table and CSV output.
+var queryColumns = []string{"Account", "Principal Type", "Principal", "Role"}
+
+func queryRow(a identitydsl.Assignment) []string {
+	return []string{a.Account.ID, a.PrincipalType(), a.PrincipalName(), a.Role.Name}
+}
+
+func writeTable(assignments []identitydsl.Assignment) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(queryColumns, "\t")))
+
+	for _, a := range assignments {
+		fmt.Fprintln(w, strings.Join(queryRow(a), "\t"))
+	}
+
+	return w.Flush()
+}
+
+func writeCSV(assignments []identitydsl.Assignment) error {
+	w := csv.NewWriter(os.Stdout)
+
+	if err := w.Write(queryColumns); err != nil {
+		return err
+	}
+
+	for _, a := range assignments {
+		if err := w.Write(queryRow(a)); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+func writeJSON(assignments []identitydsl.Assignment) error {
+	type row struct {
+		Account       string `json:"account"`
+		PrincipalType string `json:"principal_type"`
+		Principal     string `json:"principal"`
+		Role          string `json:"role"`
+	}
+
+	rows := make([]row, len(assignments))
+
+	for i, a := range assignments {
+		rows[i] = row{a.Account.ID, a.PrincipalType(), a.PrincipalName(), a.Role.Name}
+	}
+
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+
+	return e.Encode(rows)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+	"github.com/xdesign-jheather/identitydsl/pkg/terraform"
+)
+
+// outputs names the file written for each format.
+var outputs = map[string]string{
+	"json": "identitydsl.tf.json",
+	"hcl":  "identitydsl.tf",
+}
+
+func synth(args []string) error {
+	flags := flag.NewFlagSet("synth", flag.ExitOnError)
+
+	provider := flags.String("provider", "terraform", "IaC provider to synthesize for")
+	format := flags.String("format", "json", "output format, json or hcl")
+	compact := flags.Bool("compact", false, "make one assignment resource per principal and role, for each of its accounts")
+
+	files, err := parseArgs(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(files) != 1 {
+		return errors.New("synth expects a single file or directory")
+	}
+
+	if *provider != "terraform" {
+		return fmt.Errorf("unsupported provider %s", *provider)
+	}
+
+	output, ok := outputs[*format]
+
+	if !ok {
+		return fmt.Errorf("unsupported format %s", *format)
+	}
+
+	doc, err := load(files[0])
+
+	if err != nil {
+		return err
+	}
+
+	if err := report(files[0], identitydsl.Enforce(doc)); err != nil {
+		return err
+	}
+
+	assignments, err := identitydsl.Expand(doc)
+
+	if err != nil {
+		return err
+	}
+
+	addresses, err := loadAddresses()
+
+	if err != nil {
+		return err
+	}
+
+	model := identitydsl.NewModel(doc)
+
+	if err := report(files[0], model.ReadPolicies()); err != nil {
+		return err
+	}
+
+	config := terraform.Synthesize(model, assignments, addresses, terraform.Options{
+		Compact: *compact,
+	})
+
+	var data []byte
+
+	switch *format {
+	case "json":
+		data, err = config.JSON()
+
+		if err != nil {
+			return err
+		}
+	case "hcl":
+		data = config.HCL()
+	}
+
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return err
+	}
+
+	fmt.Println("Wrote", output)
+
+	// The output of another format would declare every resource again, which
+	// Terraform rejects, so it is removed when switching formats.
+	for _, other := range outputs {
+		if other == output {
+			continue
+		}
+
+		if err := os.Remove(other); err == nil {
+			fmt.Println("Removed", other)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	data, err = json.MarshalIndent(addresses, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(addressesFile, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	fmt.Println("Wrote", addressesFile)
+
+	return nil
+}
+
+// addressesFile keeps the names of synthesized resources between runs, and
+// should be committed along with the output.
+const addressesFile = "identitydsl.addresses.json"
+
+// loadAddresses reads the addresses kept by a previous run, if there was one.
+func loadAddresses() (*terraform.Addresses, error) {
+	addresses := &terraform.Addresses{}
+
+	data, err := os.ReadFile(addressesFile)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return addresses, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, addresses); err != nil {
+		return nil, fmt.Errorf("%s: %v", addressesFile, err)
+	}
+
+	return addresses, nil
+}
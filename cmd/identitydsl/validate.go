@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+func validate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+
+	files, err := parseArgs(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(files) != 1 {
+		return errors.New("validate expects a single file or directory")
+	}
+
+	doc, err := load(files[0])
+
+	if err != nil {
+		return err
+	}
+
+	return report(files[0], identitydsl.Enforce(doc))
+}
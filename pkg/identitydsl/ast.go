@@ -0,0 +1,155 @@
+package identitydsl
+
+// Kind is the kind of entity a block declares or selects.
+type Kind int
+
+const (
+	KindAccount Kind = iota + 1
+	KindUser
+	KindGroup
+	KindRole
+	KindOU
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindAccount:
+		return "Account"
+	case KindUser:
+		return "User"
+	case KindGroup:
+		return "Group"
+	case KindRole:
+		return "Role"
+	case KindOU:
+		return "OU"
+	}
+
+	return "Unknown"
+}
+
+// Document is a parsed DSL file.
+type Document struct {
+	Blocks   []Block    // top level blocks, in the order written
+	Comments []*Comment // every comment, in the order written
+}
+
+// Block is a declaration or assignment, or a context enclosing assignments.
+type Block interface {
+	Pos() Position
+	block()
+}
+
+// Comment is a line comment, including the leading slashes.
+type Comment struct {
+	Start Position
+	Text  string
+}
+
+// Value is a single bare or quoted value, without the quotes.
+type Value struct {
+	Start Position
+	Text  string
+}
+
+// Pair is the key and value of a tag.
+type Pair struct {
+	Key   Value
+	Value Value
+}
+
+// EntityBlock declares one or more accounts, users, groups or organizational
+// units, each having the same labels and tags. Groups may also select the
+// users who are their members, and accounts and OUs may name the OU they are
+// in with their OU or Parent property.
+type EntityBlock struct {
+	Start      Position
+	Kind       Kind
+	IDs        []Value
+	Labels     []Value
+	Tags       []Pair
+	Members    []*Selection
+	Properties []Pair
+}
+
+// RoleBlock declares one or more roles, each having the same policies and
+// properties.
+type RoleBlock struct {
+	Start      Position
+	Names      []Value
+	Policies   []Value
+	Properties []Pair
+}
+
+// AssignBlock assigns roles to users and groups in accounts, with a selection
+// for each kind of entity involved.
+type AssignBlock struct {
+	Start      Position
+	Selections []*Selection
+}
+
+// DenyBlock forbids assignments of roles to users and groups in accounts,
+// selecting them the same way as an Assign block. An assignment is forbidden
+// when it matches every selection, unless it matches any of the exceptions.
+type DenyBlock struct {
+	Start      Position
+	Selections []*Selection
+	Exceptions []*Selection
+}
+
+// ContextBlock narrows down the entities of one kind available to the blocks
+// nested within it.
+type ContextBlock struct {
+	Start     Position
+	Kind      Kind
+	Selectors []Selector
+	Blocks    []Block
+}
+
+// IncludeBlock names, with a glob pattern relative to the file it is written
+// in, the files whose blocks are included in place of it.
+type IncludeBlock struct {
+	Start   Position
+	Pattern Value
+}
+
+// Selection is a line of an Assign or Deny block selecting entities of one
+// kind.
+type Selection struct {
+	Start     Position
+	Kind      Kind
+	Selectors []Selector
+}
+
+// Selector picks entities by ID or label when it is a single value, or by tag
+// when it has a key as well. A selector of accounts may instead pick those
+// within an OU, following the OU keyword.
+type Selector struct {
+	Key   *Value
+	Value Value
+	OU    *Position // where the OU keyword is, when picking by OU
+}
+
+// IsTag reports whether the selector picks entities by tag.
+func (s Selector) IsTag() bool {
+	return s.Key != nil
+}
+
+// IsOU reports whether the selector picks accounts by OU.
+func (s Selector) IsOU() bool {
+	return s.OU != nil
+}
+
+func (b *EntityBlock) Pos() Position  { return b.Start }
+func (b *RoleBlock) Pos() Position    { return b.Start }
+func (b *AssignBlock) Pos() Position  { return b.Start }
+func (b *DenyBlock) Pos() Position    { return b.Start }
+func (b *ContextBlock) Pos() Position { return b.Start }
+func (b *IncludeBlock) Pos() Position { return b.Start }
+
+func (*EntityBlock) block()  {}
+func (*RoleBlock) block()    {}
+func (*AssignBlock) block()  {}
+func (*DenyBlock) block()    {}
+func (*ContextBlock) block() {}
+func (*IncludeBlock) block() {}
@@ -1,18 +1,19 @@
 package identitydsl
 
-import (
-	"fmt"
-	"strings"
-)
+// Check parses, validates and enforces the input, returning every problem
+// found. The logical checks are only made once the input parses without
+// error, as a partial document would give misleading results, and Deny blocks
+// are only enforced once it is valid.
+func Check(input string) error {
+	doc, err := Parse(input)
 
-func Check(input string) {
-	l := lexer{
-		input: input,
+	if err != nil {
+		return err
 	}
 
-	l.run(lexDSL)
-
-	for i := range l.items {
-		fmt.Println(strings.Replace(fmt.Sprintf("%#v", l.items[i]), "identitydsl.lexeme", "", -1))
+	if err := Validate(doc); err != nil {
+		return err
 	}
+
+	return Enforce(doc)
 }
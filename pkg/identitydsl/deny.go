@@ -0,0 +1,137 @@
+package identitydsl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Enforce checks the assignments made by a valid document against its Deny
+// blocks, reporting each forbidden assignment at every Assign block which
+// made it, in the order they appear in the input.
+func Enforce(doc *Document) error {
+	var errs Errors
+
+	// A valid document expands without error.
+	assignments, _ := Expand(doc)
+
+	for _, b := range doc.Blocks {
+		d, ok := b.(*DenyBlock)
+
+		if !ok {
+			continue
+		}
+
+		for _, a := range assignments {
+			if !d.Denies(a) {
+				continue
+			}
+
+			principal := KindGroup
+
+			if a.User != nil {
+				principal = KindUser
+			}
+
+			for _, s := range a.Sources {
+				denied := fmt.Sprintf("on line %d", d.Start.Line)
+
+				if d.Start.File != s.Assign.Start.File {
+					denied = fmt.Sprintf("in %s on line %d", d.Start.File, d.Start.Line)
+				}
+
+				errs = append(errs, &Error{
+					Pos: s.Assign.Start,
+					Msg: fmt.Sprintf("Assign on line %d gives %s %s Role %s in Account %s, denied %s", s.Assign.Start.Line, principal, a.PrincipalName(), a.Role.Name, a.Account.ID, denied),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.before(errs[j].Pos)
+	})
+
+	return errs.Err()
+}
+
+// Denies reports whether the Deny block forbids the assignment. Each line
+// narrows down the entities of its kind the same way as in an Assign block,
+// and a kind without a line is not narrowed down at all. A User line picks
+// the assignments of the groups a user is a member of as well as those made
+// to the user. An Except line only exempts the assignments made directly to
+// the entities it selects.
+func (b *DenyBlock) Denies(a Assignment) bool {
+	lines := map[Kind][][]Selector{}
+
+	for _, s := range b.Selections {
+		lines[s.Kind] = append(lines[s.Kind], s.Selectors)
+	}
+
+	users, groups := lines[KindUser], lines[KindGroup]
+	principal := users == nil && groups == nil
+
+	if a.User != nil && users != nil {
+		principal = picked(users, a.User.Name, a.User.Attributes)
+	}
+
+	if a.Group != nil {
+		if groups != nil && picked(groups, a.Group.Name, a.Group.Attributes) {
+			principal = true
+		}
+
+		for _, m := range a.Group.Members {
+			if users != nil && picked(users, m.User.Name, m.User.Attributes) {
+				principal = true
+			}
+		}
+	}
+
+	if !principal || !picked(lines[KindAccount], a.Account.ID, a.Account.Attributes) || !picked(lines[KindRole], a.Role.Name, Attributes{}) {
+		return false
+	}
+
+	for _, s := range b.Exceptions {
+		if id, attributes, ok := a.entity(s.Kind); ok && picked([][]Selector{s.Selectors}, id, attributes) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// picked reports whether an entity is picked by a selector on every line.
+func picked(lines [][]Selector, id string, a Attributes) bool {
+	for _, selectors := range lines {
+		found := false
+
+		for _, s := range selectors {
+			if s.Matches(id, a) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// entity returns the ID and attributes of the entity of the given kind which
+// the assignment is made of, if there is one.
+func (a Assignment) entity(kind Kind) (string, Attributes, bool) {
+	switch {
+	case kind == KindAccount:
+		return a.Account.ID, a.Account.Attributes, true
+	case kind == KindUser && a.User != nil:
+		return a.User.Name, a.User.Attributes, true
+	case kind == KindGroup && a.Group != nil:
+		return a.Group.Name, a.Group.Attributes, true
+	case kind == KindRole:
+		return a.Role.Name, Attributes{}, true
+	}
+
+	return "", Attributes{}, false
+}
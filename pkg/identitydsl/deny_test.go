@@ -0,0 +1,118 @@
+package identitydsl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDenies(t *testing.T) {
+	const entities = `OU Workloads
+OU Production
+	Parent Workloads
+Account 111111111111
+	OU Production
+Account 222222222222
+	Sandbox
+User Alice
+	Team Data
+User Carol
+Group DBA
+	Members Carol
+Role ReadOnly, ReadWrite
+Assign
+	Account 111111111111, 222222222222
+	Role ReadOnly, ReadWrite
+	User Alice
+	Group DBA
+`
+
+	deny := func(name, block string, want ...string) {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Parse(entities + block)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assignments, err := Expand(doc)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			d := doc.Blocks[len(doc.Blocks)-1].(*DenyBlock)
+
+			var denied []Assignment
+
+			for _, a := range assignments {
+				if d.Denies(a) {
+					denied = append(denied, a)
+				}
+			}
+
+			if got := tuples(denied); got != strings.Join(want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+			}
+		})
+	}
+
+	deny(
+		"ou subtree",
+		"Deny\n\tAccount OU Workloads\n\tRole ReadWrite",
+		"111111111111 USER Alice ReadWrite",
+		"111111111111 GROUP DBA ReadWrite",
+	)
+
+	deny(
+		"member",
+		"Deny\n\tUser Carol\n\tRole ReadOnly",
+		"111111111111 GROUP DBA ReadOnly",
+		"222222222222 GROUP DBA ReadOnly",
+	)
+
+	deny(
+		"lines narrow down",
+		"Deny\n\tAccount Sandbox, 111111111111\n\tAccount Sandbox\n\tGroup DBA\n\tRole ReadOnly",
+		"222222222222 GROUP DBA ReadOnly",
+	)
+
+	deny(
+		"except",
+		"Deny\n\tRole ReadWrite\n\tExcept User Team Data\n\tExcept Account Sandbox",
+		"111111111111 GROUP DBA ReadWrite",
+	)
+
+	deny(
+		"except member",
+		"Deny\n\tAccount Sandbox\n\tRole ReadOnly\n\tExcept User Carol",
+		"222222222222 USER Alice ReadOnly",
+		"222222222222 GROUP DBA ReadOnly",
+	)
+}
+
+func TestEnforce(t *testing.T) {
+	doc, err := Parse(`Account 111111111111
+Group Admins
+Role AdministratorAccess
+Deny
+	Role AdministratorAccess
+Assign
+	Account 111111111111
+	Role AdministratorAccess
+	Group Admins`)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Guardrails are left to Enforce, so the document is still valid.
+	if err := Validate(doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "Assign on line 6 gives Group Admins Role AdministratorAccess in Account 111111111111, denied on line 4"
+
+	if err := Enforce(doc); err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+}
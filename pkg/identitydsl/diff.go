@@ -0,0 +1,174 @@
+package identitydsl
+
+// Diff is the difference in access given by two versions of a document.
+type Diff struct {
+	// Added are the assignments only made by the new version, and Removed
+	// those only made by the old one.
+	Added   []Assignment
+	Removed []Assignment
+
+	// Roles are the roles whose policies or properties differ.
+	Roles []RoleDiff
+}
+
+// RoleDiff is the difference in the policies and properties of a role. A role
+// only in one version has all of its policies and properties added or
+// removed.
+type RoleDiff struct {
+	Name       string
+	Added      []Policy
+	Removed    []Policy
+	Properties []PropertyDiff
+}
+
+// PropertyDiff is a property of a role which differs, with its value in each
+// version, or empty where it is not set. The value of the Inline property is
+// the policy document rather than the file it was read from.
+type PropertyDiff struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// Empty reports whether the versions give the same access.
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Roles) == 0
+}
+
+// Compare expands two versions of a document and works out the difference in
+// the access they give, reading the inline policies of both. Assignments are
+// listed in the order of the version they are found in, and roles in the order
+// of the new version followed by any removed.
+func Compare(oldDoc, newDoc *Document) (*Diff, error) {
+	before, err := Expand(oldDoc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := Expand(newDoc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diff{
+		Added:   missing(after, before),
+		Removed: missing(before, after),
+	}
+
+	oldModel, newModel := NewModel(oldDoc), NewModel(newDoc)
+
+	for _, m := range []*Model{oldModel, newModel} {
+		if err := m.ReadPolicies(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, r := range newModel.Roles {
+		d.compareRole(r.Name, oldModel.Role(r.Name), r)
+	}
+
+	for _, r := range oldModel.Roles {
+		if newModel.Role(r.Name) == nil {
+			d.compareRole(r.Name, r, nil)
+		}
+	}
+
+	return d, nil
+}
+
+// compareRole compares two versions of a role, either of which may be nil
+// when the role is only in the other version.
+func (d *Diff) compareRole(name string, before, after *Role) {
+	if before == nil {
+		before = &Role{}
+	}
+
+	if after == nil {
+		after = &Role{}
+	}
+
+	rd := RoleDiff{
+		Name:    name,
+		Added:   missingPolicies(after.Policies, before.Policies),
+		Removed: missingPolicies(before.Policies, after.Policies),
+	}
+
+	old, updated := before.properties(), after.properties()
+
+	for i := range old {
+		if old[i].value != updated[i].value {
+			rd.Properties = append(rd.Properties, PropertyDiff{old[i].name, old[i].value, updated[i].value})
+		}
+	}
+
+	if len(rd.Added) > 0 || len(rd.Removed) > 0 || len(rd.Properties) > 0 {
+		d.Roles = append(d.Roles, rd)
+	}
+}
+
+// property is the value of a property of a role, empty where it is not set.
+type property struct {
+	name  string
+	value string
+}
+
+// properties lists the properties of a role which are compared, in the same
+// order for every role.
+func (r *Role) properties() []property {
+	var inline, duration string
+
+	if r.Inline != nil {
+		inline = r.Inline.Document
+	}
+
+	if r.SessionDuration != 0 {
+		duration = FormatSessionDuration(r.SessionDuration)
+	}
+
+	return []property{
+		{"Description", r.Description},
+		{"SessionDuration", duration},
+		{"RelayState", r.RelayState},
+		{"Boundary", string(r.Boundary)},
+		{"Inline", inline},
+	}
+}
+
+// missing returns the assignments in a which are not in b.
+func missing(a, b []Assignment) []Assignment {
+	found := map[assignmentKey]bool{}
+
+	for _, x := range b {
+		found[keyOf(x)] = true
+	}
+
+	var out []Assignment
+
+	for _, x := range a {
+		if !found[keyOf(x)] {
+			out = append(out, x)
+		}
+	}
+
+	return out
+}
+
+func missingPolicies(a, b []Policy) []Policy {
+	found := map[Policy]bool{}
+
+	for _, p := range b {
+		found[p] = true
+	}
+
+	var out []Policy
+
+	for _, p := range a {
+		if !found[p] {
+			out = append(out, p)
+		}
+	}
+
+	return out
+}
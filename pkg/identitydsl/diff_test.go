@@ -0,0 +1,139 @@
+package identitydsl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	parse := func(input string) *Document {
+		doc, err := Parse(input)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return doc
+	}
+
+	oldDoc := parse(`Account 111111111111
+	Production
+Account 222222222222
+Group DBA
+Role ReadOnly
+Role Admin
+	AdminPolicy
+Role Old
+Assign
+	Account Production
+	Role ReadOnly, Admin, Old
+	Group DBA`)
+
+	newDoc := parse(`Account 111111111111
+Account 222222222222
+	Production
+Group DBA
+Role ReadOnly
+Role Admin
+	AdminPolicy
+	ExtraPolicy
+Role New
+Assign
+	Account Production
+	Role ReadOnly, Admin
+	Group DBA`)
+
+	d, err := Compare(oldDoc, newDoc)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := tuples(d.Added), "222222222222 GROUP DBA ReadOnly\n222222222222 GROUP DBA Admin"; got != want {
+		t.Errorf("got added:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got, want := tuples(d.Removed), "111111111111 GROUP DBA ReadOnly\n111111111111 GROUP DBA Admin\n111111111111 GROUP DBA Old"; got != want {
+		t.Errorf("got removed:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got, want := fmt.Sprint(d.Roles), "[{Admin [ExtraPolicy] [] []} {New [New] [] []} {Old [] [Old] []}]"; got != want {
+		t.Errorf("got roles %s, want %s", got, want)
+	}
+
+	if d.Empty() {
+		t.Error("got empty diff")
+	}
+
+	d, err = Compare(oldDoc, oldDoc)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !d.Empty() {
+		t.Errorf("got diff %v comparing a document with itself", d)
+	}
+}
+
+func TestCompareProperties(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"old.json": `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}}`,
+		"new.json": `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*"}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	load := func(input string) *Document {
+		doc, err := LoadSource(filepath.Join(dir, "main.idsl"), input)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return doc
+	}
+
+	oldDoc := load(`Role ReadOnly
+	Inline old.json
+	Boundary /security/Boundary
+	SessionDuration 8h
+	Description "Read only"
+Role Admin
+	Inline old.json
+	RelayState https://example.com/`)
+	newDoc := load(`Role ReadOnly
+	Inline new.json
+	Boundary arn:aws:iam::aws:policy/PowerUserAccess
+	SessionDuration PT8H
+	Description "Read only"
+Role Admin
+	RelayState https://example.com/`)
+
+	d, err := Compare(oldDoc, newDoc)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	old := `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}}`
+	updated := `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"s3:*","Resource":"*"}}`
+
+	want := fmt.Sprint([]RoleDiff{
+		{Name: "ReadOnly", Properties: []PropertyDiff{
+			{"Boundary", "/security/Boundary", "arn:aws:iam::aws:policy/PowerUserAccess"},
+			{"Inline", old, updated},
+		}},
+		{Name: "Admin", Properties: []PropertyDiff{{"Inline", old, ""}}},
+	})
+
+	if got := fmt.Sprint(d.Roles); got != want {
+		t.Errorf("got roles %s, want %s", got, want)
+	}
+}
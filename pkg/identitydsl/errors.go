@@ -0,0 +1,35 @@
+package identitydsl
+
+import "strings"
+
+// Error is a problem found in the input, along with where it was found.
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// Errors is every problem found in the input, in the order they were found.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+
+	for i := range e {
+		messages[i] = e[i].Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Err returns the list as an error, or nil when it is empty.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
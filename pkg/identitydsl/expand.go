@@ -0,0 +1,244 @@
+package identitydsl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Expand works out every assignment made by the Assign blocks in a document.
+// Each Assign makes the product of the accounts, users and groups, and roles
+// it selects, after the contexts enclosing it have narrowed them down. Each
+// selection line narrows down the entities of its kind further, so a second
+// Account line picks accounts matching both lines.
+//
+// Assignments made by more than one Assign block are only included once, in
+// the order first made, with a source for each block. Every selector which
+// picks nothing from the entities available to it is reported as an error,
+// and the assignments are returned regardless.
+func Expand(doc *Document) ([]Assignment, error) {
+	m := NewModel(doc)
+
+	e := expansion{
+		model: m,
+		seen:  map[assignmentKey]int{},
+	}
+
+	e.blocks(doc.Blocks, scope{
+		accounts: m.Accounts,
+		users:    m.Users,
+		groups:   m.Groups,
+		roles:    m.Roles,
+	})
+
+	sort.SliceStable(e.errors, func(i, j int) bool {
+		return e.errors[i].Pos.before(e.errors[j].Pos)
+	})
+
+	return e.assignments, e.errors.Err()
+}
+
+type expansion struct {
+	model       *Model
+	assignments []Assignment
+	seen        map[assignmentKey]int
+	errors      Errors
+}
+
+// assignmentKey identifies an assignment by the names of what it is made of.
+type assignmentKey struct {
+	account, principalType, principal, role string
+}
+
+func keyOf(a Assignment) assignmentKey {
+	return assignmentKey{a.Account.ID, a.PrincipalType(), a.PrincipalName(), a.Role.Name}
+}
+
+// scope holds the entities available to a block once the contexts enclosing
+// it are applied, and which kinds have been selected.
+type scope struct {
+	accounts []*Account
+	users    []*User
+	groups   []*Group
+	roles    []*Role
+	selected map[Kind]bool
+	contexts []*ContextBlock
+	filters  []filter
+}
+
+// filter is a narrowing of a scope by a line of selectors.
+type filter struct {
+	kind      Kind
+	selectors []Selector
+}
+
+// narrow returns a copy of the scope with entities of the given kind filtered
+// down to those the selectors pick, reporting any selector picking nothing.
+func (e *expansion) narrow(s scope, kind Kind, selectors []Selector) scope {
+	n := s
+	n.selected = map[Kind]bool{kind: true}
+	n.filters = append(s.filters[:len(s.filters):len(s.filters)], filter{kind, selectors})
+
+	for k := range s.selected {
+		n.selected[k] = true
+	}
+
+	matched := make([]bool, len(selectors))
+
+	pick := func(id string, a Attributes) bool {
+		var picked bool
+
+		for i := range selectors {
+			if selectors[i].Matches(id, a) {
+				matched[i], picked = true, true
+			}
+		}
+
+		return picked
+	}
+
+	switch kind {
+	case KindAccount:
+		n.accounts = nil
+
+		for _, a := range s.accounts {
+			if pick(a.ID, a.Attributes) {
+				n.accounts = append(n.accounts, a)
+			}
+		}
+	case KindUser:
+		n.users = nil
+
+		for _, u := range s.users {
+			if pick(u.Name, u.Attributes) {
+				n.users = append(n.users, u)
+			}
+		}
+	case KindGroup:
+		n.groups = nil
+
+		for _, g := range s.groups {
+			if pick(g.Name, g.Attributes) {
+				n.groups = append(n.groups, g)
+			}
+		}
+	case KindRole:
+		n.roles = nil
+
+		for _, r := range s.roles {
+			if pick(r.Name, Attributes{}) {
+				n.roles = append(n.roles, r)
+			}
+		}
+	}
+
+	for i, s := range selectors {
+		if !matched[i] {
+			e.errorf(s.Start(), "%s selector '%s' matches nothing on line %d", kind, s, s.Start().Line)
+		}
+	}
+
+	return n
+}
+
+func (e *expansion) errorf(pos Position, format string, args ...interface{}) {
+	e.errors = append(e.errors, &Error{
+		Pos: pos,
+		Msg: fmt.Sprintf(format, args...),
+	})
+}
+
+func (e *expansion) blocks(blocks []Block, s scope) {
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *ContextBlock:
+			n := e.narrow(s, b.Kind, b.Selectors)
+			n.contexts = append(s.contexts[:len(s.contexts):len(s.contexts)], b)
+
+			e.blocks(b.Blocks, n)
+		case *AssignBlock:
+			e.assign(b, s)
+		}
+	}
+}
+
+// assign makes the assignments for an Assign block. Entities of a kind are
+// only included when the block or an enclosing context selects that kind.
+func (e *expansion) assign(b *AssignBlock, s scope) {
+	for _, sel := range b.Selections {
+		s = e.narrow(s, sel.Kind, sel.Selectors)
+	}
+
+	if !s.selected[KindAccount] || !s.selected[KindRole] {
+		return
+	}
+
+	for _, a := range s.accounts {
+		for _, r := range s.roles {
+			if s.selected[KindUser] {
+				for _, u := range s.users {
+					e.add(b, s, Assignment{Account: a, User: u, Role: r})
+				}
+			}
+
+			if s.selected[KindGroup] {
+				for _, g := range s.groups {
+					e.add(b, s, Assignment{Account: a, Group: g, Role: r})
+				}
+			}
+		}
+	}
+}
+
+// add includes an assignment made by an Assign block, or adds the block as
+// another source when the assignment has already been made.
+func (e *expansion) add(b *AssignBlock, s scope, a Assignment) {
+	source := Source{
+		Assign:   b,
+		Contexts: s.contexts,
+		Matches:  matches(s.filters, a),
+	}
+
+	key := keyOf(a)
+
+	if i, ok := e.seen[key]; ok {
+		e.assignments[i].Sources = append(e.assignments[i].Sources, source)
+		return
+	}
+
+	a.Sources = []Source{source}
+
+	e.seen[key] = len(e.assignments)
+	e.assignments = append(e.assignments, a)
+}
+
+// matches lists the selectors in the filters which pick the entities of an
+// assignment.
+func matches(filters []filter, a Assignment) []Match {
+	var out []Match
+
+	for _, f := range filters {
+		var id string
+		var attributes Attributes
+
+		switch {
+		case f.kind == KindAccount:
+			id, attributes = a.Account.ID, a.Account.Attributes
+		case f.kind == KindUser && a.User != nil:
+			id, attributes = a.User.Name, a.User.Attributes
+		case f.kind == KindGroup && a.Group != nil:
+			id, attributes = a.Group.Name, a.Group.Attributes
+		case f.kind == KindRole:
+			id = a.Role.Name
+		default:
+			continue
+		}
+
+		for _, sel := range f.selectors {
+			if sel.Matches(id, attributes) {
+				out = append(out, Match{f.kind, sel})
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,257 @@
+package identitydsl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// tuples summarises assignments for comparison.
+func tuples(assignments []Assignment) string {
+	lines := make([]string, len(assignments))
+
+	for i, a := range assignments {
+		lines[i] = a.Account.ID + " " + a.PrincipalType() + " " + a.PrincipalName() + " " + a.Role.Name
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func TestExpand(t *testing.T) {
+	expand := func(t *testing.T, name, input string, want ...string) {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Parse(input)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assignments, err := Expand(doc)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := tuples(assignments); got != strings.Join(want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+			}
+		})
+	}
+
+	const entities = `Account 111111111111, 222222222222
+	Team Data
+	Environment Dev
+Account 333333333333
+	Team Data
+	Environment Production
+Account 444444444444
+	Snowflake
+User Alice, Bob
+Group DataTeam
+Role ReadOnly, ReadWrite
+`
+
+	expand(
+		t,
+		"product",
+		entities+`Assign
+	Account 111111111111, Snowflake
+	Role ReadOnly, ReadWrite
+	User Alice
+	Group DataTeam`,
+		"111111111111 USER Alice ReadOnly",
+		"111111111111 GROUP DataTeam ReadOnly",
+		"111111111111 USER Alice ReadWrite",
+		"111111111111 GROUP DataTeam ReadWrite",
+		"444444444444 USER Alice ReadOnly",
+		"444444444444 GROUP DataTeam ReadOnly",
+		"444444444444 USER Alice ReadWrite",
+		"444444444444 GROUP DataTeam ReadWrite",
+	)
+
+	expand(
+		t,
+		"nested contexts",
+		entities+`Accounts Team Data
+	Accounts Environment Dev
+		Assign
+			Role ReadWrite
+			Group DataTeam
+	Assign
+		Account Environment Production
+		Role ReadOnly
+		User Bob`,
+		"111111111111 GROUP DataTeam ReadWrite",
+		"222222222222 GROUP DataTeam ReadWrite",
+		"333333333333 USER Bob ReadOnly",
+	)
+
+	expand(
+		t,
+		"principal contexts",
+		entities+`Users Alice
+	Assign
+		Account 444444444444
+		Role ReadOnly`,
+		"444444444444 USER Alice ReadOnly",
+	)
+
+	expand(
+		t,
+		"duplicates",
+		entities+`Assign
+	Account Team Data
+	Role ReadOnly
+	User Alice
+Accounts 111111111111
+	Assign
+		Role ReadOnly, ReadWrite
+		User Alice, Bob`,
+		"111111111111 USER Alice ReadOnly",
+		"222222222222 USER Alice ReadOnly",
+		"333333333333 USER Alice ReadOnly",
+		"111111111111 USER Bob ReadOnly",
+		"111111111111 USER Alice ReadWrite",
+		"111111111111 USER Bob ReadWrite",
+	)
+
+	expand(
+		t,
+		"lines narrow",
+		entities+`Assign
+	Account Team Data
+	Account Environment Production, 222222222222
+	Role ReadOnly
+	Group DataTeam`,
+		"222222222222 GROUP DataTeam ReadOnly",
+		"333333333333 GROUP DataTeam ReadOnly",
+	)
+
+	expand(
+		t,
+		"organizational units",
+		`OU Workloads
+OU Production, Dev
+	Parent Workloads
+OU Data
+	Parent Production
+Account 111111111111
+	OU Data
+Account 222222222222
+	OU Dev
+Account 333333333333
+	OU Workloads
+Account 444444444444
+Group DataTeam, Platform
+Role ReadOnly
+
+Accounts OU Workloads
+	Assign
+		Account OU Production
+		Role ReadOnly
+		Group DataTeam
+	Assign
+		Role ReadOnly
+		Group Platform`,
+		"111111111111 GROUP DataTeam ReadOnly",
+		"111111111111 GROUP Platform ReadOnly",
+		"222222222222 GROUP Platform ReadOnly",
+		"333333333333 GROUP Platform ReadOnly",
+	)
+
+	expand(
+		t,
+		"ou tags",
+		`Account 111111111111
+	"OU" Engineering
+Account 222222222222
+	"OU" Finance
+Group DataTeam
+Role ReadOnly
+
+Assign
+	Account "OU" Engineering
+	Role ReadOnly
+	Group DataTeam`,
+		"111111111111 GROUP DataTeam ReadOnly",
+	)
+
+	t.Run("selectors matching nothing", func(t *testing.T) {
+		doc, err := Parse(entities + `Accounts Team Data
+	Assign
+		Account Snowflake, Environment Dev
+		Role ReadOnly, Missing
+		User Team Data
+		Group DataTeam`)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assignments, err := Expand(doc)
+
+		want := []string{
+			"111111111111 GROUP DataTeam ReadOnly",
+			"222222222222 GROUP DataTeam ReadOnly",
+		}
+
+		if got := tuples(assignments); got != strings.Join(want, "\n") {
+			t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+		}
+
+		if got, want := fmt.Sprint(err), strings.Join([]string{
+			"Account selector 'Snowflake' matches nothing on line 14",
+			"Role selector 'Missing' matches nothing on line 15",
+			"User selector 'Team Data' matches nothing on line 16",
+		}, "\n"); got != want {
+			t.Errorf("got errors:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("sources", func(t *testing.T) {
+		doc, err := Parse(entities + `Assign
+	Account Team Data
+	Role ReadOnly
+	User Alice
+Accounts 111111111111
+	Assign
+		Account Environment Dev
+		Role ReadOnly, ReadWrite
+		User Alice, Bob`)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assignments, err := Expand(doc)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var got []string
+
+		for _, source := range assignments[0].Sources {
+			line := fmt.Sprintf("Assign %d:", source.Assign.Pos().Line)
+
+			for _, c := range source.Contexts {
+				line += fmt.Sprintf(" in %d", c.Pos().Line)
+			}
+
+			for _, m := range source.Matches {
+				line += fmt.Sprintf(" %s(%s)", m.Kind, m.Selector)
+			}
+
+			got = append(got, line)
+		}
+
+		want := []string{
+			"Assign 12: Account(Team Data) Role(ReadOnly) User(Alice)",
+			"Assign 17: in 16 Account(111111111111) Account(Environment Dev) Role(ReadOnly) User(Alice)",
+		}
+
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package identitydsl
+
+import "slices"
+
+// Filter picks out assignments. Each field left empty matches any assignment,
+// and an assignment must match every field which is set. A user is taken to
+// have the assignments of the groups they are a member of.
+type Filter struct {
+	Account string
+	User    string
+	Group   string
+	Role    string
+
+	// OU matches accounts in the OU or any OU beneath it.
+	OU string
+
+	// Tags and Labels must each be found on the account, user or group of an
+	// assignment.
+	Tags   []Tag
+	Labels []Label
+}
+
+// Matches reports whether the filter picks the assignment.
+func (f Filter) Matches(a Assignment) bool {
+	switch {
+	case f.Account != "" && a.Account.ID != f.Account:
+		return false
+	case f.User != "" && !a.Includes(f.User):
+		return false
+	case f.Group != "" && (a.Group == nil || a.Group.Name != f.Group):
+		return false
+	case f.Role != "" && a.Role.Name != f.Role:
+		return false
+	case f.OU != "" && !slices.Contains(a.Account.ous, f.OU):
+		return false
+	}
+
+	principal := a.principalAttributes()
+
+	for _, t := range f.Tags {
+		if !a.Account.hasTag(t) && !principal.hasTag(t) {
+			return false
+		}
+	}
+
+	for _, l := range f.Labels {
+		if !a.Account.HasLabel(string(l)) && !principal.HasLabel(string(l)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply returns the assignments the filter picks.
+func (f Filter) Apply(assignments []Assignment) []Assignment {
+	var out []Assignment
+
+	for _, a := range assignments {
+		if f.Matches(a) {
+			out = append(out, a)
+		}
+	}
+
+	return out
+}
+
+// hasTag reports whether the entity has the tag.
+func (a Attributes) hasTag(t Tag) bool {
+	value, ok := a.Tag(t.Key)
+	return ok && value == t.Value
+}
+
+func (a Assignment) principalAttributes() Attributes {
+	if a.User != nil {
+		return a.User.Attributes
+	}
+
+	return a.Group.Attributes
+}
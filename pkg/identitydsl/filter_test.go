@@ -0,0 +1,95 @@
+package identitydsl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	doc, err := Parse(`OU Workloads
+OU Production
+	Parent Workloads
+Account 111111111111
+	Environment Production
+	OU Production
+Account 222222222222
+	Sandbox
+User Alice
+	Team Data
+User Carol
+Group DBA
+	Sandbox
+	Members Carol
+Role ReadOnly, ReadWrite
+Assign
+	Account 111111111111, 222222222222
+	Role ReadOnly, ReadWrite
+	User Alice
+	Group DBA`)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assignments, err := Expand(doc)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	filter := func(name string, f Filter, want ...string) {
+		t.Run(name, func(t *testing.T) {
+			if got := tuples(f.Apply(assignments)); got != strings.Join(want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+			}
+		})
+	}
+
+	filter(
+		"account and role",
+		Filter{Account: "111111111111", Role: "ReadWrite"},
+		"111111111111 USER Alice ReadWrite",
+		"111111111111 GROUP DBA ReadWrite",
+	)
+
+	filter(
+		"group",
+		Filter{Group: "DBA", Role: "ReadOnly"},
+		"111111111111 GROUP DBA ReadOnly",
+		"222222222222 GROUP DBA ReadOnly",
+	)
+
+	filter(
+		"user",
+		Filter{User: "DBA"},
+	)
+
+	filter(
+		"member",
+		Filter{User: "Carol", Role: "ReadOnly"},
+		"111111111111 GROUP DBA ReadOnly",
+		"222222222222 GROUP DBA ReadOnly",
+	)
+
+	filter(
+		"tags",
+		Filter{Tags: []Tag{{"Environment", "Production"}, {"Team", "Data"}}},
+		"111111111111 USER Alice ReadOnly",
+		"111111111111 USER Alice ReadWrite",
+	)
+
+	filter(
+		"ou",
+		Filter{OU: "Workloads", Group: "DBA"},
+		"111111111111 GROUP DBA ReadOnly",
+		"111111111111 GROUP DBA ReadWrite",
+	)
+
+	filter(
+		"labels",
+		Filter{Labels: []Label{"Sandbox"}, Role: "ReadOnly"},
+		"111111111111 GROUP DBA ReadOnly",
+		"222222222222 USER Alice ReadOnly",
+		"222222222222 GROUP DBA ReadOnly",
+	)
+}
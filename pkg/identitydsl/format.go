@@ -0,0 +1,255 @@
+package identitydsl
+
+import (
+	"sort"
+	"strings"
+)
+
+// Format prints a document in its canonical form. Lines are indented with
+// tabs, lists are separated by a comma and a space, values are only quoted
+// when they need to be, and blocks are set apart by a single blank line.
+//
+// Comments are kept on their own lines, placed by where they were written.
+// A comment takes the indentation of the line after it, unless it was
+// written deeper, at the end of a block. A blank line written after a
+// comment is kept.
+func Format(doc *Document) []byte {
+	var p printer
+
+	p.blocks(doc.Blocks, 0)
+
+	return p.merge(doc.Comments)
+}
+
+// printer collects the lines of a formatted document.
+type printer struct {
+	lines []printed
+}
+
+// printed is a formatted line, with the line it was written on.
+type printed struct {
+	depth   int
+	text    string
+	line    int
+	column  int
+	block   bool
+	comment bool
+}
+
+func (p *printer) add(depth int, pos Position, block bool, text string) {
+	p.lines = append(p.lines, printed{
+		depth:  depth,
+		text:   text,
+		line:   pos.Line,
+		column: pos.Column,
+		block:  block,
+	})
+}
+
+func (p *printer) blocks(blocks []Block, depth int) {
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *EntityBlock:
+			p.add(depth, b.Start, true, b.Kind.String()+" "+values(b.IDs))
+
+			// Labels, tags, members and properties are kept in the order
+			// they were written.
+			var lines []Value
+
+			for _, v := range b.Labels {
+				lines = append(lines, Value{v.Start, quote(v.Text)})
+			}
+
+			for _, t := range b.Tags {
+				key := quote(t.Key.Text)
+
+				// A bare key naming a property would be read as the property.
+				if (b.Kind == KindAccount && key == "OU") || (b.Kind == KindOU && key == "Parent") {
+					key = `"` + key + `"`
+				}
+
+				lines = append(lines, Value{t.Key.Start, key + " " + quote(t.Value.Text)})
+			}
+
+			for _, s := range b.Members {
+				lines = append(lines, Value{s.Start, "Members " + selectorList(s.Selectors)})
+			}
+
+			for _, pr := range b.Properties {
+				lines = append(lines, Value{pr.Key.Start, pr.Key.Text + " " + quote(pr.Value.Text)})
+			}
+
+			sort.SliceStable(lines, func(i, j int) bool {
+				return lines[i].Start.Offset < lines[j].Start.Offset
+			})
+
+			for _, v := range lines {
+				p.add(depth+1, v.Start, false, v.Text)
+			}
+		case *RoleBlock:
+			p.add(depth, b.Start, true, "Role "+values(b.Names))
+
+			// Policies and properties are kept in the order they were
+			// written.
+			var lines []Value
+
+			for _, v := range b.Policies {
+				lines = append(lines, Value{v.Start, quote(v.Text)})
+			}
+
+			for _, pr := range b.Properties {
+				value := pr.Value.Text
+
+				if strings.ContainsAny(value, " \t") {
+					value = `"` + value + `"`
+				}
+
+				lines = append(lines, Value{pr.Key.Start, pr.Key.Text + " " + value})
+			}
+
+			sort.SliceStable(lines, func(i, j int) bool {
+				return lines[i].Start.Offset < lines[j].Start.Offset
+			})
+
+			for _, v := range lines {
+				p.add(depth+1, v.Start, false, v.Text)
+			}
+		case *AssignBlock:
+			p.add(depth, b.Start, true, "Assign")
+
+			for _, s := range b.Selections {
+				p.add(depth+1, s.Start, false, s.Kind.String()+" "+selectorList(s.Selectors))
+			}
+		case *DenyBlock:
+			p.add(depth, b.Start, true, "Deny")
+
+			// Exceptions are kept among the selections where they were
+			// written.
+			var lines []Value
+
+			for _, s := range b.Selections {
+				lines = append(lines, Value{s.Start, s.Kind.String() + " " + selectorList(s.Selectors)})
+			}
+
+			for _, s := range b.Exceptions {
+				lines = append(lines, Value{s.Start, "Except " + s.Kind.String() + " " + selectorList(s.Selectors)})
+			}
+
+			sort.SliceStable(lines, func(i, j int) bool {
+				return lines[i].Start.Offset < lines[j].Start.Offset
+			})
+
+			for _, v := range lines {
+				p.add(depth+1, v.Start, false, v.Text)
+			}
+		case *ContextBlock:
+			p.add(depth, b.Start, true, b.Kind.String()+"s "+selectorList(b.Selectors))
+			p.blocks(b.Blocks, depth+1)
+		case *IncludeBlock:
+			p.add(depth, b.Start, true, `Include "`+b.Pattern.Text+`"`)
+		}
+	}
+}
+
+func values(list []Value) string {
+	texts := make([]string, len(list))
+
+	for i, v := range list {
+		texts[i] = quote(v.Text)
+	}
+
+	return strings.Join(texts, ", ")
+}
+
+func selectorList(list []Selector) string {
+	texts := make([]string, len(list))
+
+	for i, s := range list {
+		texts[i] = s.String()
+	}
+
+	return strings.Join(texts, ", ")
+}
+
+// merge places the comments among the printed lines and writes them out.
+func (p *printer) merge(comments []*Comment) []byte {
+	var all []printed
+
+	// columns holds the column each depth was last written at, to work out
+	// the depth of a comment from where it was written.
+	var columns []int
+
+	i := 0
+
+	for _, c := range comments {
+		for ; i < len(p.lines) && p.lines[i].line < c.Start.Line; i++ {
+			columns = append(columns[:p.lines[i].depth], p.lines[i].column)
+			all = append(all, p.lines[i])
+		}
+
+		depth := 0
+
+		if i < len(p.lines) {
+			depth = p.lines[i].depth
+		}
+
+		for d := len(columns) - 1; d > depth; d-- {
+			if columns[d] <= c.Start.Column {
+				depth = d
+				break
+			}
+		}
+
+		all = append(all, printed{
+			depth:   depth,
+			text:    strings.TrimRight(c.Text, " \t"),
+			line:    c.Start.Line,
+			comment: true,
+		})
+	}
+
+	all = append(all, p.lines[i:]...)
+
+	var b strings.Builder
+
+	for i, l := range all {
+		if i > 0 && blankBefore(all, i) {
+			b.WriteByte('\n')
+		}
+
+		b.WriteString(strings.Repeat("\t", l.depth) + l.text + "\n")
+	}
+
+	return []byte(b.String())
+}
+
+// blankBefore reports whether a blank line goes before a line. Each block is
+// set apart, along with the comments leading up to it at its depth or above,
+// and blank lines written next to comments are kept.
+func blankBefore(all []printed, i int) bool {
+	prev, l := all[i-1], all[i]
+
+	if (prev.comment || l.comment) && l.line > prev.line+1 {
+		return true
+	}
+
+	next := i
+
+	for next < len(all) && all[next].comment {
+		next++
+	}
+
+	if next == len(all) || !all[next].block {
+		return false
+	}
+
+	depth := all[next].depth
+
+	for _, c := range all[i:next] {
+		if c.depth > depth {
+			return false
+		}
+	}
+
+	return !prev.comment || prev.depth > depth
+}
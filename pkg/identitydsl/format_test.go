@@ -0,0 +1,190 @@
+package identitydsl
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	format := func(t *testing.T, name, input, want string) {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Parse(input)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := string(Format(doc))
+
+			if got != want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+			}
+
+			// Formatting again changes nothing, and means the same.
+			again, err := Parse(got)
+
+			if err != nil {
+				t.Fatalf("unexpected error formatting again: %v", err)
+			}
+
+			if twice := string(Format(again)); twice != got {
+				t.Errorf("formatting again got:\n%s\nwant:\n%s", twice, got)
+			}
+
+			if outline(again.Blocks) != outline(doc.Blocks) {
+				t.Errorf("got %s, want %s", outline(again.Blocks), outline(doc.Blocks))
+			}
+		})
+	}
+
+	format(t, "empty", "", "")
+
+	format(
+		t,
+		"spacing and quotes",
+		"Account 123456789012,098765432109\n    \"Owner\"   Legal\n    \"Production\"\n    \"Website DR\"\nUser   Bob\nRole ReadOnly,Admin\n    Policy\n",
+		`Account 123456789012, 098765432109
+	Owner Legal
+	Production
+	"Website DR"
+
+User Bob
+
+Role ReadOnly, Admin
+	Policy
+`,
+	)
+
+	format(
+		t,
+		"contexts",
+		`Accounts  Team Data
+  Assign
+    Role ReadOnly
+    Group "DBA",Ops
+  Accounts Environment   "Dev"
+    Assign
+      Role ReadWrite
+      Group DBA
+
+
+Assign
+  Account "Team Data"
+  Role Other
+  User Bob`,
+		`Accounts Team Data
+
+	Assign
+		Role ReadOnly
+		Group DBA, Ops
+
+	Accounts Environment Dev
+
+		Assign
+			Role ReadWrite
+			Group DBA
+
+Assign
+	Account "Team Data"
+	Role Other
+	User Bob
+`,
+	)
+
+	format(
+		t,
+		"comments",
+		`// Accounts
+
+Account 123456789012
+// Labels
+	Production
+	// End of the account
+// Roles
+Role ReadOnly
+Assign
+	Account Production
+	// Only the one role
+	Role ReadOnly
+	Group DBA
+	// End of the assign
+
+// End`,
+		`// Accounts
+
+Account 123456789012
+	// Labels
+	Production
+	// End of the account
+
+// Roles
+Role ReadOnly
+
+Assign
+	Account Production
+	// Only the one role
+	Role ReadOnly
+	Group DBA
+	// End of the assign
+
+// End
+`,
+	)
+	format(
+		t,
+		"include",
+		"Include   \"teams/*.idsl\"\nInclude \"Data Team/*.idsl\"\nUser Bob\n",
+		`Include "teams/*.idsl"
+
+Include "Data Team/*.idsl"
+
+User Bob
+`,
+	)
+	format(
+		t,
+		"members",
+		"Group DBA\n  Production\n  Members   Alice,Team   Data\nUser Alice\n",
+		`Group DBA
+	Production
+	Members Alice, Team Data
+
+User Alice
+`,
+	)
+	format(
+		t,
+		"deny",
+		"Deny\n  Account   Production\n  Except  Group BreakGlass\n  Role AdministratorAccess\n",
+		`Deny
+	Account Production
+	Except Group BreakGlass
+	Role AdministratorAccess
+`,
+	)
+	format(
+		t,
+		"role properties",
+		"Role ReadOnly\n  Policy\n  Description   \"Read only\"\n  RelayState \"https://example.com\"\n",
+		`Role ReadOnly
+	Policy
+	Description "Read only"
+	RelayState https://example.com
+`,
+	)
+	format(
+		t,
+		"organizational units",
+		"OU   Production\n  Parent Workloads\n  \"Parent\" Legal\nAccount 123456789012\n  OU   Production\n  \"OU\" Engineering\nAccounts OU Workloads\n  Assign\n    Account OU Production, \"OU\" Engineering\n",
+		`OU Production
+	Parent Workloads
+	"Parent" Legal
+
+Account 123456789012
+	OU Production
+	"OU" Engineering
+
+Accounts OU Workloads
+
+	Assign
+		Account OU Production, "OU" Engineering
+`,
+	)
+}
@@ -1,30 +1,120 @@
 package identitydsl
 
+import (
+	"fmt"
+	"strings"
+)
+
 const valueRunes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_+=.@-"
 
 type stateFunc func(*lexer) stateFunc
 
+// lex lexes the whole input, returning every lexeme along with every error.
+func lex(input string) (lexemes, Errors) {
+	return lexFile("", input)
+}
+
+// lexFile lexes the input read from a file, recording the file in the
+// position of every lexeme.
+func lexFile(file, input string) (lexemes, Errors) {
+	l := lexer{
+		file:  file,
+		input: input,
+	}
+
+	l.run(lexDSL)
+
+	return l.items, l.items.errors()
+}
+
+// lexDSL lexes the start of a line, working out its indentation level from
+// the leading whitespace before handing over to the lexer for that level.
+// Indentation deeper than the previous line is only permitted when that line
+// can have a body, such as the tags or labels beneath an Account.
 func lexDSL(l *lexer) stateFunc {
-	if l.peek() == eof {
+	l.acceptRun(" \t")
+
+	switch l.peek() {
+	case eof:
+		l.ignore()
+		l.dedent(0)
 		l.emit(typeEOF)
 		return nil
-	}
-
-	if l.acceptRun("\r\n") {
+	case '\r', '\n':
+		l.ignore()
+		l.acceptRun("\r\n")
 		l.emit(typeEOL)
 		return lexDSL
 	}
 
 	if l.peekString("//") {
+		l.ignore()
 		return lexComment
 	}
 
+	depth, problem := l.indentation(l.value())
+
+	if problem != "" {
+		return l.errorf("%s on line %d", problem, l.line())
+	}
+
+	body := l.body
+	l.body = nil
+
+	switch {
+	case depth > l.depth+1:
+		return l.errorf("Skipped indentation level on line %d", l.line())
+	case depth > l.depth:
+		if body == nil {
+			return l.errorf("Unexpected indentation on line %d", l.line())
+		}
+		l.scopes = append(l.scopes, body)
+		l.depth = depth
+		l.emit(typeIndent)
+	default:
+		l.ignore()
+		l.dedent(depth)
+	}
+
+	if depth == 0 {
+		return lexStatement
+	}
+
+	return l.scopes[depth-1]
+}
+
+// lexStatement lexes a line which is not indented.
+func lexStatement(l *lexer) stateFunc {
+	if l.peekString("Accounts ") {
+		return lexAccounts
+	}
+
+	if l.acceptString("Accounts") && (l.peek() == eof || l.accept("\r\n")) {
+		return l.errorf("Accounts not specified on line %d", l.line())
+	}
+
+	if l.peekString("Users ") {
+		return lexUsers
+	}
+
+	if l.acceptString("Users") && (l.peek() == eof || l.accept("\r\n")) {
+		return l.errorf("Users not specified on line %d", l.line())
+	}
+
+	if l.peekString("Groups ") {
+		return lexGroups
+	}
+
+	if l.acceptString("Groups") && (l.peek() == eof || l.accept("\r\n")) {
+		return l.errorf("Groups not specified on line %d", l.line())
+	}
+
 	if l.peekString("Account ") {
 		return lexAccount
 	}
 
 	if l.acceptString("Account") && (l.peek() == eof || l.accept("\r\n")) {
-		return l.errorf("Account not specified on line %d", l.items.currentLineNumber())
+		return l.errorf("Account not specified on line %d", l.line())
 	}
 
 	if l.peekString("User ") {
@@ -32,7 +122,7 @@ func lexDSL(l *lexer) stateFunc {
 	}
 
 	if l.acceptString("User") && (l.peek() == eof || l.accept("\r\n")) {
-		return l.errorf("User not specified on line %d", l.items.currentLineNumber())
+		return l.errorf("User not specified on line %d", l.line())
 	}
 
 	if l.peekString("Group ") {
@@ -40,7 +130,7 @@ func lexDSL(l *lexer) stateFunc {
 	}
 
 	if l.acceptString("Group") && (l.peek() == eof || l.accept("\r\n")) {
-		return l.errorf("Group not specified on line %d", l.items.currentLineNumber())
+		return l.errorf("Group not specified on line %d", l.line())
 	}
 
 	if l.peekString("Role ") {
@@ -48,15 +138,60 @@ func lexDSL(l *lexer) stateFunc {
 	}
 
 	if l.acceptString("Role") && (l.peek() == eof || l.accept("\r\n")) {
-		return l.errorf("Role not specified on line %d", l.items.currentLineNumber())
+		return l.errorf("Role not specified on line %d", l.line())
+	}
+
+	if l.peekString("OU ") {
+		return lexOU
+	}
+
+	if l.acceptString("OU") && (l.peek() == eof || l.accept("\r\n")) {
+		return l.errorf("OU not specified on line %d", l.line())
+	}
+
+	if l.peekString("Include ") {
+		return lexInclude
+	}
+
+	if l.acceptString("Include") && (l.peek() == eof || l.accept("\r\n")) {
+		return l.errorf("Include not specified on line %d", l.line())
+	}
+
+	if l.peekString("Assign") {
+		return lexAssign
+	}
+
+	if l.peekString("Deny") {
+		return lexDeny
 	}
 
 	return lexUnknown
 }
 
+// lexRecover skips the rest of a line containing an error, along with any
+// lines after it until one which is not indented, so lexing can carry on from
+// the next top level statement and report any further errors.
+func lexRecover(l *lexer) stateFunc {
+	for {
+		l.acceptToLineEnding()
+
+		if !l.acceptRun("\r\n") {
+			l.ignore()
+			return lexDSL
+		}
+
+		if r := l.peek(); r != ' ' && r != '\t' && r != '\r' && r != '\n' && !l.peekString("//") {
+			l.ignore()
+			l.body = nil
+			l.dedent(0)
+			return lexDSL
+		}
+	}
+}
+
 func lexUnknown(l *lexer) stateFunc {
 	l.acceptToLineEnding()
-	return l.errorf("Unknown input '%s' on line %d", l.value(), l.items.currentLineNumber())
+	return l.errorf("Unknown input '%s' on line %d", l.value(), l.line())
 }
 
 func lexComment(l *lexer) stateFunc {
@@ -67,18 +202,19 @@ func lexComment(l *lexer) stateFunc {
 
 func lexAccount(l *lexer) stateFunc {
 	l.acceptString("Account")
-	l.ignore()
-	l.emit(typeAccount)
+	l.emitKeyword(typeAccount)
+
+	l.body = lexAccountBody
 	l.acceptRun(" ")
 	l.ignore()
 
 	for pos := 1; ; pos++ {
 		if !l.acceptRun("1234567890") {
-			return l.errorf("Invalid account ID on line %d position %d", l.items.currentLineNumber(), pos)
+			return l.errorf("Invalid account ID on line %d position %d", l.line(), pos)
 		}
 
 		if len(l.value()) != 12 {
-			return l.errorf("Bad length account ID on line %d position %d", l.items.currentLineNumber(), pos)
+			return l.errorf("Bad length account ID on line %d position %d", l.line(), pos)
 		}
 
 		l.emit(typeValue)
@@ -99,19 +235,126 @@ func lexAccount(l *lexer) stateFunc {
 		}
 	}
 
-	return lexTagsOrLabels
+	return lexDSL
 }
 
 func lexGroup(l *lexer) stateFunc {
 	l.acceptString("Group")
+	l.emitKeyword(typeGroup)
+
+	l.body = lexGroupBody
+	l.acceptRun(" ")
 	l.ignore()
-	l.emit(typeGroup)
+
+	for pos := 1; ; pos++ {
+		if !l.acceptRun(valueRunes) {
+			return l.errorf("Invalid group ID on line %d position %d", l.line(), pos)
+		}
+
+		l.emit(typeValue)
+
+		if l.acceptRun(", ") {
+			l.ignore()
+			continue
+		}
+
+		if l.peek() == eof {
+			return lexDSL
+		}
+
+		if r := l.peek(); r == '\r' || r == '\n' {
+			l.acceptRun("\r\n")
+			l.emit(typeEOL)
+			break
+		}
+	}
+
+	return lexDSL
+}
+
+// lexGroupBody lexes a line beneath a Group, which is either a Members line
+// selecting the users in the group, or a label or tag.
+func lexGroupBody(l *lexer) stateFunc {
+	if l.peekString("Members ") {
+		l.acceptString("Members")
+		l.emitKeyword(typeMembers)
+		l.acceptRun(" ")
+		l.ignore()
+		return lexSelectorList
+	}
+
+	if l.acceptString("Members") {
+		if r := l.peek(); r == eof || r == '\r' || r == '\n' {
+			return l.errorf("Members not specified on line %d", l.line())
+		}
+
+		// A label or tag which happens to start with the word.
+		l.pos = l.start
+	}
+
+	return lexTagsOrLabels(l)
+}
+
+// lexAccountBody lexes a line beneath an Account, which is either an OU line
+// naming the OU the account is in, or a label or tag.
+func lexAccountBody(l *lexer) stateFunc {
+	return lexEntityProperty(l, "OU")
+}
+
+// lexOUBody lexes a line beneath an OU, which is either a Parent line naming
+// the OU above it, or a label or tag.
+func lexOUBody(l *lexer) stateFunc {
+	return lexEntityProperty(l, "Parent")
+}
+
+// lexEntityProperty lexes a line beneath an entity which may be the property
+// with the given name, naming an OU. A tag with the same key can still be
+// given by quoting the key.
+func lexEntityProperty(l *lexer, name string) stateFunc {
+	if l.peekString(name + " ") {
+		l.acceptString(name)
+		l.emit(typeProperty)
+		l.acceptRun(" ")
+		l.ignore()
+
+		found, problem := lexValue(l)
+
+		switch {
+		case problem != "":
+			return l.errorf("%s on line %d", problem, l.line())
+		case !found:
+			return l.errorf("%s not specified on line %d", name, l.line())
+		}
+
+		l.acceptRun(" ")
+		l.ignore()
+
+		return lexLineEnding
+	}
+
+	if l.acceptString(name) {
+		if r := l.peek(); r == eof || r == '\r' || r == '\n' {
+			return l.errorf("%s not specified on line %d", name, l.line())
+		}
+
+		// A label or tag which happens to start with the word.
+		l.pos = l.start
+	}
+
+	return lexTagsOrLabels(l)
+}
+
+func lexOU(l *lexer) stateFunc {
+	l.acceptString("OU")
+	l.emitKeyword(typeOU)
+
+	l.body = lexOUBody
 	l.acceptRun(" ")
 	l.ignore()
 
 	for pos := 1; ; pos++ {
 		if !l.acceptRun(valueRunes) {
-			return l.errorf("Invalid group ID on line %d position %d", l.items.currentLineNumber(), pos)
+			return l.errorf("Invalid OU ID on line %d position %d", l.line(), pos)
 		}
 
 		l.emit(typeValue)
@@ -132,19 +375,20 @@ func lexGroup(l *lexer) stateFunc {
 		}
 	}
 
-	return lexTagsOrLabels
+	return lexDSL
 }
 
 func lexUser(l *lexer) stateFunc {
 	l.acceptString("User")
-	l.ignore()
-	l.emit(typeUser)
+	l.emitKeyword(typeUser)
+
+	l.body = lexTagsOrLabels
 	l.acceptRun(" ")
 	l.ignore()
 
 	for pos := 1; ; pos++ {
 		if !l.acceptRun(valueRunes) {
-			return l.errorf("Invalid user ID on line %d position %d", l.items.currentLineNumber(), pos)
+			return l.errorf("Invalid user ID on line %d position %d", l.line(), pos)
 		}
 
 		l.emit(typeValue)
@@ -165,19 +409,20 @@ func lexUser(l *lexer) stateFunc {
 		}
 	}
 
-	return lexTagsOrLabels
+	return lexDSL
 }
 
 func lexRole(l *lexer) stateFunc {
 	l.acceptString("Role")
-	l.ignore()
-	l.emit(typeRole)
+	l.emitKeyword(typeRole)
+
+	l.body = lexRoleBody
 	l.acceptRun(" ")
 	l.ignore()
 
 	for pos := 1; ; pos++ {
 		if !l.acceptRun(valueRunes) {
-			return l.errorf("Invalid role ID on line %d position %d", l.items.currentLineNumber(), pos)
+			return l.errorf("Invalid role ID on line %d position %d", l.line(), pos)
 		}
 
 		l.emit(typeValue)
@@ -198,82 +443,421 @@ func lexRole(l *lexer) stateFunc {
 		}
 	}
 
+	return lexDSL
+}
+
+// roleProperties are the properties of the permission set which can be set
+// beneath a Role, each on its own line.
+var roleProperties = []string{"SessionDuration", "RelayState", "Description", "Inline", "Boundary"}
+
+// lexRoleBody lexes a line beneath a Role, which is either a property or a
+// policy.
+func lexRoleBody(l *lexer) stateFunc {
+	for _, p := range roleProperties {
+		if l.peekString(p + " ") {
+			l.acceptString(p)
+			l.emit(typeProperty)
+			l.acceptRun(" ")
+			l.ignore()
+
+			if p == "Boundary" {
+				return lexPolicyReference
+			}
+
+			return lexPropertyValue
+		}
+	}
+
 	return lexPolicies
 }
 
-func lexPolicies(l *lexer) stateFunc {
-	if !l.acceptRun("\t") {
-		return lexDSL
+// lexPolicyReference lexes the value of a property naming a policy, which is
+// either the ARN of a managed policy or the name of a customer managed policy
+// with an optional path before it, such as /security/Boundary.
+func lexPolicyReference(l *lexer) stateFunc {
+	name := l.items[len(l.items)-1].val
+
+	if !l.acceptRun(valueRunes + ":/") {
+		return l.errorf("%s not specified on line %d", name, l.line())
+	}
+
+	if problem := Policy(l.value()).check(); problem != "" {
+		return l.errorf("%s on line %d", problem, l.line())
+	}
+
+	l.emit(typeValue)
+
+	return lexLineEnding
+}
+
+// lexPropertyValue lexes the value of a property, which is either everything
+// up to the next space, such as a URL, or double quoted and then may hold any
+// character other than a quote.
+func lexPropertyValue(l *lexer) stateFunc {
+	name := l.items[len(l.items)-1].val
+
+	if !l.accept(`"`) {
+		for r := l.peek(); r != eof && r != '\r' && r != '\n' && r != ' ' && r != '\t'; r = l.peek() {
+			l.next()
+		}
+
+		if l.value() == "" {
+			return l.errorf("%s not specified on line %d", name, l.line())
+		}
+
+		l.emit(typeValue)
+
+		return lexLineEnding
 	}
 
-	l.emit(typeSpace)
+	l.ignore()
+
+	for r := l.peek(); r != '"'; r = l.peek() {
+		if r == eof || r == '\r' || r == '\n' {
+			return l.errorf("Unclosed quoted value on line %d", l.line())
+		}
+
+		l.next()
+	}
 
-	if !l.acceptRun(valueRunes) {
-		return l.errorf("No policies found on line %d", l.items.currentLineNumber())
+	if l.value() == "" {
+		return l.errorf("Empty value on line %d", l.line())
 	}
 
 	l.emit(typeValue)
+	l.next()
+	l.ignore()
 
-	switch l.peek() {
+	return lexLineEnding
+}
+
+// lexPolicies lexes a policy attached to a role, given in the same way as a
+// policy named by a property.
+func lexPolicies(l *lexer) stateFunc {
+	if !l.acceptRun(valueRunes + ":/") {
+		return l.errorf("No policies found on line %d", l.line())
+	}
+
+	if problem := Policy(l.value()).check(); problem != "" {
+		return l.errorf("%s on line %d", problem, l.line())
+	}
+
+	l.emit(typeValue)
+
+	return lexLineEnding
+}
+
+func lexTagsOrLabels(l *lexer) stateFunc {
+	for i := 0; i < 2; i++ {
+		if _, problem := lexValue(l); problem != "" {
+			return l.errorf("%s on line %d", problem, l.line())
+		}
+
+		if !l.acceptRun(" ") {
+			break
+		}
+
+		l.ignore()
+	}
+
+	return lexLineEnding
+}
+
+// lexLineEnding expects nothing more on the current line.
+func lexLineEnding(l *lexer) stateFunc {
+	switch r := l.peek(); r {
 	case eof:
 		return lexDSL
 	case '\r', '\n':
 		l.acceptRun("\r\n")
 		l.emit(typeEOL)
+		return lexDSL
+	default:
+		l.acceptToLineEnding()
+		return l.errorf("Unexpected input '%s' on line %d", l.value(), l.line())
 	}
+}
 
-	return lexPolicies
+// lexValue emits a single bare or double quoted value. It reports whether a
+// value was found, and describes the problem if the value is malformed.
+func lexValue(l *lexer) (found bool, problem string) {
+	if l.peek() != '"' {
+		if !l.acceptRun(valueRunes) {
+			return false, ""
+		}
+
+		l.emit(typeValue)
+
+		return true, ""
+	}
+
+	l.next()
+	l.ignore()
+
+	if l.peek() == '"' {
+		return false, "Empty value"
+	}
+
+	l.acceptRun(valueRunes + " ")
+
+	switch r := l.peek(); r {
+	case '"':
+		l.emit(typeValue)
+		l.next()
+		l.ignore()
+		return true, ""
+	case '\r', '\n', eof:
+		return false, "Unclosed quoted value"
+	default:
+		return false, fmt.Sprintf("Invalid character %s", string(r))
+	}
 }
 
-func lexTagsOrLabels(l *lexer) stateFunc {
-	if !l.acceptRun("\t") {
-		return lexDSL
+// lexInclude lexes the double quoted pattern naming the files to include.
+// Being a path, it may hold any character other than a quote.
+func lexInclude(l *lexer) stateFunc {
+	l.acceptString("Include")
+	l.emitKeyword(typeInclude)
+
+	l.acceptRun(" ")
+	l.ignore()
+
+	if !l.accept(`"`) {
+		return l.errorf("Include pattern must be double quoted on line %d", l.line())
 	}
 
-	l.emit(typeSpace)
+	l.ignore()
 
-	for i := 0; i < 2; i++ {
-		if l.peek() == '"' {
+	for r := l.peek(); r != '"'; r = l.peek() {
+		if r == eof || r == '\r' || r == '\n' {
+			return l.errorf("Unclosed include pattern on line %d", l.line())
+		}
 
-			l.next()
+		l.next()
+	}
+
+	if l.value() == "" {
+		return l.errorf("Empty include pattern on line %d", l.line())
+	}
+
+	l.emit(typeValue)
+	l.next()
+	l.ignore()
+
+	return lexLineEnding
+}
+
+func lexAssign(l *lexer) stateFunc {
+	l.acceptString("Assign")
+
+	if r := l.peek(); r != eof && r != '\r' && r != '\n' {
+		return lexUnknown
+	}
+
+	l.emitKeyword(typeAssign)
+
+	l.body = lexSelectors
+
+	return lexLineEnding
+}
+
+func lexDeny(l *lexer) stateFunc {
+	l.acceptString("Deny")
+
+	if r := l.peek(); r != eof && r != '\r' && r != '\n' {
+		return lexUnknown
+	}
+
+	l.emitKeyword(typeDeny)
+
+	l.body = lexDenyBody
+
+	return lexLineEnding
+}
+
+// lexDenyBody lexes a line beneath a Deny, which selects entities the same
+// way as a line of an Assign block, optionally after Except.
+func lexDenyBody(l *lexer) stateFunc {
+	if l.peekString("Except ") {
+		l.acceptString("Except")
+		l.emitKeyword(typeExcept)
+		l.acceptRun(" ")
+		l.ignore()
+		return lexSelectors
+	}
+
+	if l.acceptString("Except") {
+		if r := l.peek(); r == eof || r == '\r' || r == '\n' {
+			return l.errorf("Except not specified on line %d", l.line())
+		}
+
+		l.pos = l.start
+	}
+
+	return lexSelectors
+}
+
+// selectorKeywords are the entity kinds which can be selected in an Assign
+// block, along with the lexeme emitted for each.
+var selectorKeywords = []struct {
+	word string
+	typ  lexemeType
+}{
+	{"Account", typeAccount},
+	{"User", typeUser},
+	{"Group", typeGroup},
+	{"Role", typeRole},
+}
+
+func lexSelectors(l *lexer) stateFunc {
+	for _, k := range selectorKeywords {
+		if l.peekString(k.word + " ") {
+			l.acceptString(k.word)
+			l.emitKeyword(k.typ)
+			l.acceptRun(" ")
 			l.ignore()
 
-			if l.peek() == '"' {
-				return l.errorf("Empty value on line %d", l.items.currentLineNumber())
+			if k.typ == typeAccount {
+				return lexAccountSelectorList
 			}
 
-			l.acceptRun(valueRunes + " ")
+			return lexSelectorList
+		}
+	}
+
+	l.acceptToLineEnding()
+
+	for _, k := range selectorKeywords {
+		if strings.TrimSpace(l.value()) == k.word {
+			return l.errorf("%s selector not specified on line %d", k.word, l.line())
+		}
+	}
+
+	return l.errorf("Unknown selector '%s' on line %d", l.value(), l.line())
+}
+
+// lexSelectorList lexes a comma delimited list of selectors, each being an
+// ID or label (one value) or a tag key value pair (two values).
+func lexSelectorList(l *lexer) stateFunc {
+	return lexSelectorsOf(l, false)
+}
+
+// lexAccountSelectorList lexes a list of selectors picking accounts, where a
+// selector may also be the OU keyword followed by the OU to pick the accounts
+// beneath.
+func lexAccountSelectorList(l *lexer) stateFunc {
+	return lexSelectorsOf(l, true)
+}
+
+func lexSelectorsOf(l *lexer, accounts bool) stateFunc {
+	for pos := 1; ; pos++ {
+		values := 2
+
+		if accounts && l.peekString("OU ") {
+			l.acceptString("OU")
+			l.emitKeyword(typeOU)
+			l.acceptRun(" ")
+			l.ignore()
+			values = 1
+		}
 
-			switch r := l.peek(); r {
-			case '"':
-				l.emit(typeValue)
-				l.next()
-				l.ignore()
+		for i := 0; i < values; i++ {
+			found, problem := lexValue(l)
 
-			case '\r', '\n':
-				return l.errorf("Unclosed quoted value on line %d", l.items.currentLineNumber())
+			if problem != "" {
+				return l.errorf("%s on line %d", problem, l.line())
+			}
 
-			default:
-				return l.errorf("Invalid character %s on line %d", string(r), l.items.currentLineNumber())
+			if !found {
+				if i == 0 {
+					return l.errorf("Missing selector on line %d position %d", l.line(), pos)
+				}
+				break
 			}
-		} else if l.acceptRun(valueRunes) {
-			l.emit(typeValue)
+
+			l.acceptRun(" ")
+			l.ignore()
 		}
 
-		if !l.acceptRun(" ") {
-			break
+		if l.accept(",") {
+			l.emit(typeComma)
+			l.acceptRun(" ")
+			l.ignore()
+			continue
 		}
 
-		l.ignore()
+		switch r := l.peek(); r {
+		case eof:
+			return lexDSL
+		case '\r', '\n':
+			l.acceptRun("\r\n")
+			l.emit(typeEOL)
+			return lexDSL
+		case '"':
+			return l.errorf("Too many values in selector on line %d position %d", l.line(), pos)
+		default:
+			if strings.ContainsRune(valueRunes, r) {
+				return l.errorf("Too many values in selector on line %d position %d", l.line(), pos)
+			}
+			return l.errorf("Invalid character %s on line %d", string(r), l.line())
+		}
 	}
+}
 
-	switch l.peek() {
-	case eof:
-		return lexDSL
-	case '\r', '\n':
-		l.acceptRun("\r\n")
-		l.emit(typeEOL)
+func lexAccounts(l *lexer) stateFunc {
+	l.acceptString("Accounts")
+	l.emitKeyword(typeAccounts)
+
+	l.body = lexContextBody
+
+	l.acceptRun(" ")
+	l.ignore()
+
+	return lexAccountSelectorList
+}
+
+func lexUsers(l *lexer) stateFunc {
+	return lexContext(l, "Users", typeUsers)
+}
+
+func lexGroups(l *lexer) stateFunc {
+	return lexContext(l, "Groups", typeGroups)
+}
+
+// lexContext lexes the header of a context, which selects entities the same
+// way as an Assign selector line does.
+func lexContext(l *lexer, word string, typ lexemeType) stateFunc {
+	l.acceptString(word)
+	l.emitKeyword(typ)
+
+	l.body = lexContextBody
+
+	l.acceptRun(" ")
+	l.ignore()
+
+	return lexSelectorList
+}
+
+// lexContextBody lexes a line nested within a context, which may only be an
+// Assign block or another context.
+func lexContextBody(l *lexer) stateFunc {
+	if l.peekString("Assign") {
+		return lexAssign
+	}
+
+	if l.peekString("Accounts ") {
+		return lexAccounts
+	}
+
+	if l.peekString("Users ") {
+		return lexUsers
 	}
 
-	return lexTagsOrLabels
+	if l.peekString("Groups ") {
+		return lexGroups
+	}
+
+	l.acceptToLineEnding()
+
+	return l.errorf("Expected Assign or context but found '%s' on line %d", l.value(), l.line())
 }
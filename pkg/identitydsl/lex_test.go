@@ -61,7 +61,7 @@ func TestLex(t *testing.T) {
 			"single",
 			"// A comment line starts with two slashes",
 			[]lexeme{
-				{typeComment, "// A comment line starts with two slashes"},
+				{typ: typeComment, val: "// A comment line starts with two slashes"},
 				{typ: typeEOF},
 			},
 		)
@@ -71,9 +71,9 @@ func TestLex(t *testing.T) {
 			"multiple",
 			"// A comment line starts with two slashes\n// Another comment!",
 			[]lexeme{
-				{typeComment, "// A comment line starts with two slashes"},
-				{typeEOL, "\n"},
-				{typeComment, "// Another comment!"},
+				{typ: typeComment, val: "// A comment line starts with two slashes"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeComment, val: "// Another comment!"},
 				{typ: typeEOF},
 			},
 		)
@@ -85,7 +85,7 @@ func TestLex(t *testing.T) {
 			"n",
 			"\n",
 			[]lexeme{
-				{typeEOL, "\n"},
+				{typ: typeEOL, val: "\n"},
 				{typ: typeEOF},
 			},
 		)
@@ -95,7 +95,7 @@ func TestLex(t *testing.T) {
 			"r",
 			"\r",
 			[]lexeme{
-				{typeEOL, "\r"},
+				{typ: typeEOL, val: "\r"},
 				{typ: typeEOF},
 			},
 		)
@@ -105,7 +105,7 @@ func TestLex(t *testing.T) {
 			"rn",
 			"\r\n",
 			[]lexeme{
-				{typeEOL, "\r\n"},
+				{typ: typeEOL, val: "\r\n"},
 				{typ: typeEOF},
 			},
 		)
@@ -117,7 +117,7 @@ func TestLex(t *testing.T) {
 			"nn",
 			"\n\n",
 			[]lexeme{
-				{typeEOL, "\n\n"},
+				{typ: typeEOL, val: "\n\n"},
 				{typ: typeEOF},
 			},
 		)
@@ -129,7 +129,8 @@ func TestLex(t *testing.T) {
 			"line 1",
 			"Hello",
 			[]lexeme{
-				{typeError, "Unknown input 'Hello' on line 1"},
+				{typ: typeError, val: "Unknown input 'Hello' on line 1"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -138,8 +139,9 @@ func TestLex(t *testing.T) {
 			"line 2",
 			"\nCheese",
 			[]lexeme{
-				{typeEOL, "\n"},
-				{typeError, "Unknown input 'Cheese' on line 2"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeError, val: "Unknown input 'Cheese' on line 2"},
+				{typ: typeEOF},
 			},
 		)
 	})
@@ -151,7 +153,8 @@ func TestLex(t *testing.T) {
 			"no identifier",
 			"Account",
 			[]lexeme{
-				{typeError, "Account not specified on line 1"},
+				{typ: typeError, val: "Account not specified on line 1"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -161,7 +164,7 @@ func TestLex(t *testing.T) {
 			"Account 112233445566",
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233445566"},
+				{typ: typeValue, val: "112233445566"},
 				{typ: typeEOF},
 			},
 		)
@@ -172,7 +175,8 @@ func TestLex(t *testing.T) {
 			"Account 1234567890",
 			[]lexeme{
 				{typ: typeAccount},
-				{typeError, "Bad length account ID on line 1 position 1"},
+				{typ: typeError, val: "Bad length account ID on line 1 position 1"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -182,7 +186,8 @@ func TestLex(t *testing.T) {
 			"Account Word",
 			[]lexeme{
 				{typ: typeAccount},
-				{typeError, "Invalid account ID on line 1 position 1"},
+				{typ: typeError, val: "Invalid account ID on line 1 position 1"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -192,10 +197,10 @@ func TestLex(t *testing.T) {
 			"Account 000000000000, 111111111111,  222222222222 , 333333333333",
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "000000000000"},
-				{typeValue, "111111111111"},
-				{typeValue, "222222222222"},
-				{typeValue, "333333333333"},
+				{typ: typeValue, val: "000000000000"},
+				{typ: typeValue, val: "111111111111"},
+				{typ: typeValue, val: "222222222222"},
+				{typ: typeValue, val: "333333333333"},
 				{typ: typeEOF},
 			},
 		)
@@ -206,8 +211,9 @@ func TestLex(t *testing.T) {
 			"Account 000000000000, Bob,  222222222222 , 333333333333",
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "000000000000"},
-				{typeError, "Invalid account ID on line 1 position 2"},
+				{typ: typeValue, val: "000000000000"},
+				{typ: typeError, val: "Invalid account ID on line 1 position 2"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -218,10 +224,11 @@ func TestLex(t *testing.T) {
 	Label1`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label1"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -233,10 +240,11 @@ func TestLex(t *testing.T) {
 	"Developer Access"`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Developer Access"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Developer Access"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -248,11 +256,12 @@ func TestLex(t *testing.T) {
 	Key1 Value1`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Key1"},
-				{typeValue, "Value1"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Key1"},
+				{typ: typeValue, val: "Value1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -264,11 +273,12 @@ func TestLex(t *testing.T) {
 	"Hello World" Value1`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Hello World"},
-				{typeValue, "Value1"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeValue, val: "Value1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -280,11 +290,12 @@ func TestLex(t *testing.T) {
 	Name "Hello World"`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeValue, "Hello World"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -296,11 +307,12 @@ func TestLex(t *testing.T) {
 	"What a World" "Hello World"`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "What a World"},
-				{typeValue, "Hello World"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "What a World"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -314,16 +326,15 @@ func TestLex(t *testing.T) {
 	"Label 3"`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label1"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label2"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label 3"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Label2"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Label 3"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -337,19 +348,18 @@ func TestLex(t *testing.T) {
 	"Favorite Pudding" "Rhubarb Crumble"`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeValue, "Jonathan"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Age"},
-				{typeValue, "36"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Favorite Pudding"},
-				{typeValue, "Rhubarb Crumble"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeValue, val: "Jonathan"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Age"},
+				{typ: typeValue, val: "36"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Favorite Pudding"},
+				{typ: typeValue, val: "Rhubarb Crumble"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -365,21 +375,19 @@ func TestLex(t *testing.T) {
 	Product Radio`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Billing"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Organisations"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Owner"},
-				{typeValue, "Platform"},
-				{typeEOL, "\n\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Product"},
-				{typeValue, "Radio"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Billing"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Organisations"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Owner"},
+				{typ: typeValue, val: "Platform"},
+				{typ: typeEOL, val: "\n\n"},
+				{typ: typeValue, val: "Product"},
+				{typ: typeValue, val: "Radio"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -391,11 +399,13 @@ func TestLex(t *testing.T) {
 	Name ""`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "123456789012"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeError, "Empty value on line 2"},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeError, val: "Empty value on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
 			},
 		)
 
@@ -406,11 +416,13 @@ func TestLex(t *testing.T) {
 	Name "?"`,
 			[]lexeme{
 				{typ: typeAccount},
-				{typeValue, "123456789012"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeError, "Invalid character ? on line 2"},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeError, val: "Invalid character ? on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
 			},
 		)
 	})
@@ -422,7 +434,8 @@ func TestLex(t *testing.T) {
 			"no identifier",
 			"Group",
 			[]lexeme{
-				{typeError, "Group not specified on line 1"},
+				{typ: typeError, val: "Group not specified on line 1"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -432,7 +445,7 @@ func TestLex(t *testing.T) {
 			"Group Developers",
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Developers"},
+				{typ: typeValue, val: "Developers"},
 				{typ: typeEOF},
 			},
 		)
@@ -443,8 +456,8 @@ func TestLex(t *testing.T) {
 			"Group Lovers, Haters",
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Lovers"},
-				{typeValue, "Haters"},
+				{typ: typeValue, val: "Lovers"},
+				{typ: typeValue, val: "Haters"},
 				{typ: typeEOF},
 			},
 		)
@@ -455,9 +468,10 @@ func TestLex(t *testing.T) {
 			"Group Lovers, Haters, !!!",
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Lovers"},
-				{typeValue, "Haters"},
-				{typeError, "Invalid group ID on line 1 position 3"},
+				{typ: typeValue, val: "Lovers"},
+				{typ: typeValue, val: "Haters"},
+				{typ: typeError, val: "Invalid group ID on line 1 position 3"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -468,10 +482,11 @@ func TestLex(t *testing.T) {
 	Label1`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Testers"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label1"},
+				{typ: typeValue, val: "Testers"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -483,10 +498,11 @@ func TestLex(t *testing.T) {
 	"Developer Access"`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Developers"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Developer Access"},
+				{typ: typeValue, val: "Developers"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Developer Access"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -498,11 +514,12 @@ func TestLex(t *testing.T) {
 	Key1 Value1`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Infosec"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Key1"},
-				{typeValue, "Value1"},
+				{typ: typeValue, val: "Infosec"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Key1"},
+				{typ: typeValue, val: "Value1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -514,11 +531,12 @@ func TestLex(t *testing.T) {
 	"Hello World" Value1`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Cheeseballs"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Hello World"},
-				{typeValue, "Value1"},
+				{typ: typeValue, val: "Cheeseballs"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeValue, val: "Value1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -530,11 +548,12 @@ func TestLex(t *testing.T) {
 	Name "Hello World"`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "TeamA"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeValue, "Hello World"},
+				{typ: typeValue, val: "TeamA"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -546,11 +565,12 @@ func TestLex(t *testing.T) {
 	"What a World" "Hello World"`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Session"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "What a World"},
-				{typeValue, "Hello World"},
+				{typ: typeValue, val: "Session"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "What a World"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -564,16 +584,15 @@ func TestLex(t *testing.T) {
 	"Label 3"`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Developers"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label1"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label2"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label 3"},
+				{typ: typeValue, val: "Developers"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Label2"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Label 3"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -587,19 +606,18 @@ func TestLex(t *testing.T) {
 	"Favorite Pudding" "Rhubarb Crumble"`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Solo"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeValue, "Jonathan"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Age"},
-				{typeValue, "36"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Favorite Pudding"},
-				{typeValue, "Rhubarb Crumble"},
+				{typ: typeValue, val: "Solo"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeValue, val: "Jonathan"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Age"},
+				{typ: typeValue, val: "36"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Favorite Pudding"},
+				{typ: typeValue, val: "Rhubarb Crumble"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -615,21 +633,19 @@ func TestLex(t *testing.T) {
 	Product Radio`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Billing"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Organisations"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Owner"},
-				{typeValue, "Platform"},
-				{typeEOL, "\n\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Product"},
-				{typeValue, "Radio"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Billing"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Organisations"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Owner"},
+				{typ: typeValue, val: "Platform"},
+				{typ: typeEOL, val: "\n\n"},
+				{typ: typeValue, val: "Product"},
+				{typ: typeValue, val: "Radio"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -641,11 +657,13 @@ func TestLex(t *testing.T) {
 	Name ""`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "TeamB"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeError, "Empty value on line 2"},
+				{typ: typeValue, val: "TeamB"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeError, val: "Empty value on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
 			},
 		)
 
@@ -656,11 +674,13 @@ func TestLex(t *testing.T) {
 	Name "?"`,
 			[]lexeme{
 				{typ: typeGroup},
-				{typeValue, "Hello"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeError, "Invalid character ? on line 2"},
+				{typ: typeValue, val: "Hello"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeError, val: "Invalid character ? on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
 			},
 		)
 	})
@@ -672,7 +692,8 @@ func TestLex(t *testing.T) {
 			"no identifier",
 			"User",
 			[]lexeme{
-				{typeError, "User not specified on line 1"},
+				{typ: typeError, val: "User not specified on line 1"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -682,7 +703,7 @@ func TestLex(t *testing.T) {
 			"User Developers",
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Developers"},
+				{typ: typeValue, val: "Developers"},
 				{typ: typeEOF},
 			},
 		)
@@ -693,8 +714,8 @@ func TestLex(t *testing.T) {
 			"User Lovers, Haters",
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Lovers"},
-				{typeValue, "Haters"},
+				{typ: typeValue, val: "Lovers"},
+				{typ: typeValue, val: "Haters"},
 				{typ: typeEOF},
 			},
 		)
@@ -705,9 +726,10 @@ func TestLex(t *testing.T) {
 			"User Lovers, Haters, !!!",
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Lovers"},
-				{typeValue, "Haters"},
-				{typeError, "Invalid user ID on line 1 position 3"},
+				{typ: typeValue, val: "Lovers"},
+				{typ: typeValue, val: "Haters"},
+				{typ: typeError, val: "Invalid user ID on line 1 position 3"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -718,10 +740,11 @@ func TestLex(t *testing.T) {
 	Label1`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Testers"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label1"},
+				{typ: typeValue, val: "Testers"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -733,10 +756,11 @@ func TestLex(t *testing.T) {
 	"Developer Access"`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Developers"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Developer Access"},
+				{typ: typeValue, val: "Developers"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Developer Access"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -748,11 +772,12 @@ func TestLex(t *testing.T) {
 	Key1 Value1`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Infosec"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Key1"},
-				{typeValue, "Value1"},
+				{typ: typeValue, val: "Infosec"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Key1"},
+				{typ: typeValue, val: "Value1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -764,11 +789,12 @@ func TestLex(t *testing.T) {
 	"Hello World" Value1`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Cheeseballs"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Hello World"},
-				{typeValue, "Value1"},
+				{typ: typeValue, val: "Cheeseballs"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeValue, val: "Value1"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -780,11 +806,12 @@ func TestLex(t *testing.T) {
 	Name "Hello World"`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "TeamA"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeValue, "Hello World"},
+				{typ: typeValue, val: "TeamA"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -796,11 +823,12 @@ func TestLex(t *testing.T) {
 	"What a World" "Hello World"`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Session"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "What a World"},
-				{typeValue, "Hello World"},
+				{typ: typeValue, val: "Session"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "What a World"},
+				{typ: typeValue, val: "Hello World"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -814,16 +842,15 @@ func TestLex(t *testing.T) {
 	"Label 3"`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Developers"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label1"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label2"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Label 3"},
+				{typ: typeValue, val: "Developers"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Label2"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Label 3"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -837,19 +864,18 @@ func TestLex(t *testing.T) {
 	"Favorite Pudding" "Rhubarb Crumble"`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Solo"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeValue, "Jonathan"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Age"},
-				{typeValue, "36"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Favorite Pudding"},
-				{typeValue, "Rhubarb Crumble"},
+				{typ: typeValue, val: "Solo"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeValue, val: "Jonathan"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Age"},
+				{typ: typeValue, val: "36"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Favorite Pudding"},
+				{typ: typeValue, val: "Rhubarb Crumble"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -865,21 +891,19 @@ func TestLex(t *testing.T) {
 	Product Radio`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "112233112233"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Billing"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Organisations"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Owner"},
-				{typeValue, "Platform"},
-				{typeEOL, "\n\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Product"},
-				{typeValue, "Radio"},
+				{typ: typeValue, val: "112233112233"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Billing"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Organisations"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Owner"},
+				{typ: typeValue, val: "Platform"},
+				{typ: typeEOL, val: "\n\n"},
+				{typ: typeValue, val: "Product"},
+				{typ: typeValue, val: "Radio"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
@@ -891,11 +915,13 @@ func TestLex(t *testing.T) {
 	Name ""`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "TeamB"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeError, "Empty value on line 2"},
+				{typ: typeValue, val: "TeamB"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeError, val: "Empty value on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
 			},
 		)
 
@@ -906,11 +932,13 @@ func TestLex(t *testing.T) {
 	Name "?"`,
 			[]lexeme{
 				{typ: typeUser},
-				{typeValue, "Hello"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "Name"},
-				{typeError, "Invalid character ? on line 2"},
+				{typ: typeValue, val: "Hello"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeError, val: "Invalid character ? on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
 			},
 		)
 	})
@@ -921,7 +949,8 @@ func TestLex(t *testing.T) {
 			"no identifier",
 			"Role",
 			[]lexeme{
-				{typeError, "Role not specified on line 1"},
+				{typ: typeError, val: "Role not specified on line 1"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -931,7 +960,7 @@ func TestLex(t *testing.T) {
 			"Role ReadOnly",
 			[]lexeme{
 				{typ: typeRole},
-				{typeValue, "ReadOnly"},
+				{typ: typeValue, val: "ReadOnly"},
 				{typ: typeEOF},
 			},
 		)
@@ -942,7 +971,8 @@ func TestLex(t *testing.T) {
 			"Role ?",
 			[]lexeme{
 				{typ: typeRole},
-				{typeError, "Invalid role ID on line 1 position 1"},
+				{typ: typeError, val: "Invalid role ID on line 1 position 1"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -952,8 +982,9 @@ func TestLex(t *testing.T) {
 			`Role ReadOnly, ?`,
 			[]lexeme{
 				{typ: typeRole},
-				{typeValue, "ReadOnly"},
-				{typeError, "Invalid role ID on line 1 position 2"},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeError, val: "Invalid role ID on line 1 position 2"},
+				{typ: typeEOF},
 			},
 		)
 
@@ -964,14 +995,165 @@ func TestLex(t *testing.T) {
 	OneMorePolicy`,
 			[]lexeme{
 				{typ: typeRole},
-				{typeValue, "ReadOnly"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "OneMorePolicy"},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "OneMorePolicy"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"properties",
+			"Role ReadOnly\n\tSessionDuration 8h\n\tRelayState https://example.com/?a=1&b=2\n\tDescription \"Read, don't write\"\n\tPolicy",
+			[]lexeme{
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeProperty, val: "SessionDuration"},
+				{typ: typeValue, val: "8h"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeProperty, val: "RelayState"},
+				{typ: typeValue, val: "https://example.com/?a=1&b=2"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeProperty, val: "Description"},
+				{typ: typeValue, val: "Read, don't write"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Policy"},
+				{typ: typeDedent},
 				{typ: typeEOF},
 			},
 		)
 
+		lex(
+			t,
+			"unclosed property",
+			"Role ReadOnly\n\tDescription \"Unclosed\n\tSessionDuration 8h",
+			[]lexeme{
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeProperty, val: "Description"},
+				{typ: typeError, val: "Unclosed quoted value on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"property with two values",
+			"Role ReadOnly\n\tSessionDuration 8h 9h",
+			[]lexeme{
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeProperty, val: "SessionDuration"},
+				{typ: typeValue, val: "8h"},
+				{typ: typeError, val: "Unexpected input ' 9h' on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"boundary",
+			"Role ReadOnly\n\tBoundary arn:aws:iam::aws:policy/PowerUserAccess\nRole Admin\n\tBoundary /security/Boundary",
+			[]lexeme{
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeProperty, val: "Boundary"},
+				{typ: typeValue, val: "arn:aws:iam::aws:policy/PowerUserAccess"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeDedent},
+				{typ: typeRole},
+				{typ: typeValue, val: "Admin"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeProperty, val: "Boundary"},
+				{typ: typeValue, val: "/security/Boundary"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"policy ARNs",
+			`Role FullAccess
+	arn:aws:iam::aws:policy/AmazonEC2FullAccess
+	arn:aws-us-gov:iam::123456789012:policy/team/Custom
+	arn:aws-cn:iam::aws:policy/job-function/ViewOnlyAccess
+	/team/Custom`,
+			[]lexeme{
+				{typ: typeRole},
+				{typ: typeValue, val: "FullAccess"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "arn:aws:iam::aws:policy/AmazonEC2FullAccess"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "arn:aws-us-gov:iam::123456789012:policy/team/Custom"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "arn:aws-cn:iam::aws:policy/job-function/ViewOnlyAccess"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "/team/Custom"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		for _, test := range []struct{ policy, problem string }{
+			{"arn:aws:iam::aws", "Invalid policy ARN 'arn:aws:iam::aws'"},
+			{"arn:aws:iam::aws:policy/a:b", "Invalid policy ARN 'arn:aws:iam::aws:policy/a:b'"},
+			{"arn:aws-eu:iam::aws:policy/PowerUserAccess", "Unknown partition 'aws-eu' in policy ARN 'arn:aws-eu:iam::aws:policy/PowerUserAccess'"},
+			{"arn:aws:s3::aws:policy/PowerUserAccess", "Service 's3' in policy ARN 'arn:aws:s3::aws:policy/PowerUserAccess' is not iam"},
+			{"arn:aws:iam:eu-west-1:aws:policy/PowerUserAccess", "Region 'eu-west-1' given in policy ARN 'arn:aws:iam:eu-west-1:aws:policy/PowerUserAccess'"},
+			{"arn:aws:iam::12345:policy/Custom", "Invalid account '12345' in policy ARN 'arn:aws:iam::12345:policy/Custom'"},
+			{"arn:aws:iam::aws:role/PowerUserAccess", "Invalid resource 'role/PowerUserAccess' in policy ARN 'arn:aws:iam::aws:role/PowerUserAccess'"},
+			{"arn:aws:iam::aws:policy/", "Invalid resource 'policy/' in policy ARN 'arn:aws:iam::aws:policy/'"},
+			{"security/Boundary", "Invalid policy path 'security/Boundary'"},
+			{"/security//Boundary", "Invalid policy path '/security//Boundary'"},
+		} {
+			lex(
+				t,
+				"invalid boundary "+test.policy,
+				"Role ReadOnly\n\tBoundary "+test.policy,
+				[]lexeme{
+					{typ: typeRole},
+					{typ: typeValue, val: "ReadOnly"},
+					{typ: typeEOL, val: "\n"},
+					{typ: typeIndent, val: "\t"},
+					{typ: typeProperty, val: "Boundary"},
+					{typ: typeError, val: test.problem + " on line 2"},
+					{typ: typeDedent},
+					{typ: typeEOF},
+				},
+			)
+
+			lex(
+				t,
+				"invalid policy "+test.policy,
+				"Role ReadOnly\n\t"+test.policy,
+				[]lexeme{
+					{typ: typeRole},
+					{typ: typeValue, val: "ReadOnly"},
+					{typ: typeEOL, val: "\n"},
+					{typ: typeIndent, val: "\t"},
+					{typ: typeError, val: test.problem + " on line 2"},
+					{typ: typeDedent},
+					{typ: typeEOF},
+				},
+			)
+		}
+
 		lex(
 			t,
 			"role many valid",
@@ -980,17 +1162,851 @@ func TestLex(t *testing.T) {
 	JustOneMorePolicy`,
 			[]lexeme{
 				{typ: typeRole},
-				{typeValue, "ReadOnly"},
-				{typeValue, "ReadAndWrite"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "OneMorePolicy"},
-				{typeEOL, "\n"},
-				{typeSpace, "\t"},
-				{typeValue, "JustOneMorePolicy"},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeValue, val: "ReadAndWrite"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "OneMorePolicy"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "JustOneMorePolicy"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+	})
+
+	t.Run("deny", func(t *testing.T) {
+		lex(
+			t,
+			"deny",
+			`Deny
+	Account Production
+	Role AdministratorAccess
+	Except Group BreakGlass`,
+			[]lexeme{
+				{typ: typeDeny},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAccount},
+				{typ: typeValue, val: "Production"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeRole},
+				{typ: typeValue, val: "AdministratorAccess"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeExcept},
+				{typ: typeGroup},
+				{typ: typeValue, val: "BreakGlass"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"except not specified",
+			"Deny\n\tExcept\n\tExcept Team Data",
+			[]lexeme{
+				{typ: typeDeny},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeError, val: "Except not specified on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"unexpected value",
+			"Deny Bob",
+			[]lexeme{
+				{typ: typeError, val: "Unknown input 'Deny Bob' on line 1"},
+				{typ: typeEOF},
+			},
+		)
+	})
+
+	t.Run("assign", func(t *testing.T) {
+		lex(
+			t,
+			"empty",
+			"Assign",
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"unexpected value",
+			"Assign Bob",
+			[]lexeme{
+				{typ: typeError, val: "Unknown input 'Assign Bob' on line 1"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"single assignment",
+			`Assign
+	Account Account1
+	Role Role1
+	Group Group1`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAccount},
+				{typ: typeValue, val: "Account1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeRole},
+				{typ: typeValue, val: "Role1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeGroup},
+				{typ: typeValue, val: "Group1"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"lists",
+			`Assign
+	Account Account1, Account2
+	Role Role1,Role2
+	User User1 ,  User2`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAccount},
+				{typ: typeValue, val: "Account1"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "Account2"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeRole},
+				{typ: typeValue, val: "Role1"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "Role2"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeUser},
+				{typ: typeValue, val: "User1"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "User2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"ids labels and tags mixed",
+			`Assign
+	Account Team Data, Snowflake, "Website DR", "Cost Centre" "CC 12"
+	Role DBAReadOnly
+	Group DBA`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAccount},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "Snowflake"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "Website DR"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "Cost Centre"},
+				{typ: typeValue, val: "CC 12"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeRole},
+				{typ: typeValue, val: "DBAReadOnly"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeGroup},
+				{typ: typeValue, val: "DBA"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"followed by another block",
+			`Assign
+	Role Role1
+
+Account 123456789012`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeRole},
+				{typ: typeValue, val: "Role1"},
+				{typ: typeEOL, val: "\n\n"},
+				{typ: typeDedent},
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"selector not specified",
+			`Assign
+	Account`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeError, val: "Account selector not specified on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"unknown selector",
+			`Assign
+	Team Data`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeError, val: "Unknown selector 'Team Data' on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"missing selector in list",
+			`Assign
+	Group A, , B`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeGroup},
+				{typ: typeValue, val: "A"},
+				{typ: typeComma, val: ","},
+				{typ: typeError, val: "Missing selector on line 2 position 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"too many values",
+			`Assign
+	Account Team Data Science`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAccount},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeError, val: "Too many values in selector on line 2 position 1"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"unclosed quote",
+			`Assign
+	Account "Website DR`,
+			[]lexeme{
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAccount},
+				{typ: typeError, val: "Unclosed quoted value on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+	})
+
+	t.Run("contexts", func(t *testing.T) {
+		lex(
+			t,
+			"no selector",
+			"Accounts",
+			[]lexeme{
+				{typ: typeError, val: "Accounts not specified on line 1"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"single assign",
+			`Accounts Team Data
+	Assign
+		Role ReadOnly
+		Group DataTeam`,
+			[]lexeme{
+				{typ: typeAccounts},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t\t"},
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeGroup},
+				{typ: typeValue, val: "DataTeam"},
+				{typ: typeDedent},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"nested",
+			`Accounts Team Data
+	Accounts Environment Dev
+
+		Assign
+			Role ReadWrite
+	Users Alice, Bob
+		Groups Admins
+			Assign
+				Role ReadOnly
+Account 123456789012`,
+			[]lexeme{
+				{typ: typeAccounts},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAccounts},
+				{typ: typeValue, val: "Environment"},
+				{typ: typeValue, val: "Dev"},
+				{typ: typeEOL, val: "\n\n"},
+				{typ: typeIndent, val: "\t\t"},
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t\t\t"},
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadWrite"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeDedent},
+				{typ: typeDedent},
+				{typ: typeUsers},
+				{typ: typeValue, val: "Alice"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "Bob"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t\t"},
+				{typ: typeGroups},
+				{typ: typeValue, val: "Admins"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t\t\t"},
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t\t\t\t"},
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeDedent},
+				{typ: typeDedent},
+				{typ: typeDedent},
+				{typ: typeDedent},
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"only assign or contexts",
+			`Accounts Team Data
+	Account 123456789012`,
+			[]lexeme{
+				{typ: typeAccounts},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeError, val: "Expected Assign or context but found 'Account 123456789012' on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+	})
+
+	t.Run("group members", func(t *testing.T) {
+		lex(
+			t,
+			"selectors",
+			"Group DBA\n\tMembers Alice, Team Data\n\tMembersOnly",
+			[]lexeme{
+				{typ: typeGroup},
+				{typ: typeValue, val: "DBA"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeMembers},
+				{typ: typeValue, val: "Alice"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "MembersOnly"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"no selector",
+			"Group DBA\n\tMembers\nUser Bob",
+			[]lexeme{
+				{typ: typeGroup},
+				{typ: typeValue, val: "DBA"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeError, val: "Members not specified on line 2"},
+				{typ: typeDedent},
+				{typ: typeUser},
+				{typ: typeValue, val: "Bob"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"only in groups",
+			"User Bob\n\tMembers Alice",
+			[]lexeme{
+				{typ: typeUser},
+				{typ: typeValue, val: "Bob"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Members"},
+				{typ: typeValue, val: "Alice"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+	})
+
+	t.Run("ou entity", func(t *testing.T) {
+		lex(
+			t,
+			"no identifier",
+			"OU",
+			[]lexeme{
+				{typ: typeError, val: "OU not specified on line 1"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"parent",
+			"OU Production, Dev\n\tParent Workloads",
+			[]lexeme{
+				{typ: typeOU},
+				{typ: typeValue, val: "Production"},
+				{typ: typeValue, val: "Dev"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeProperty, val: "Parent"},
+				{typ: typeValue, val: "Workloads"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"account ou",
+			"Account 123456789012\n\tOU Production\n\t\"OU\" Engineering\n\tOUs",
+			[]lexeme{
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeProperty, val: "OU"},
+				{typ: typeValue, val: "Production"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "OU"},
+				{typ: typeValue, val: "Engineering"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "OUs"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"missing ou",
+			"Account 123456789012\n\tOU",
+			[]lexeme{
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeError, val: "OU not specified on line 2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"selectors",
+			"Accounts OU Workloads, OU Dev\n\tAssign\n\t\tAccount OU Production, \"OU\" Engineering\n\t\tUser OU Data",
+			[]lexeme{
+				{typ: typeAccounts},
+				{typ: typeOU},
+				{typ: typeValue, val: "Workloads"},
+				{typ: typeComma, val: ","},
+				{typ: typeOU},
+				{typ: typeValue, val: "Dev"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t\t"},
+				{typ: typeAccount},
+				{typ: typeOU},
+				{typ: typeValue, val: "Production"},
+				{typ: typeComma, val: ","},
+				{typ: typeValue, val: "OU"},
+				{typ: typeValue, val: "Engineering"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeUser},
+				{typ: typeValue, val: "OU"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeDedent},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"invalid",
+			"OU Production, !!!",
+			[]lexeme{
+				{typ: typeOU},
+				{typ: typeValue, val: "Production"},
+				{typ: typeError, val: "Invalid OU ID on line 1 position 2"},
+				{typ: typeEOF},
+			},
+		)
+	})
+
+	t.Run("include", func(t *testing.T) {
+		lex(
+			t,
+			"pattern",
+			"Include \"teams/*.idsl\"\nInclude \"Data Team/roles.idsl\"",
+			[]lexeme{
+				{typ: typeInclude},
+				{typ: typeValue, val: "teams/*.idsl"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeInclude},
+				{typ: typeValue, val: "Data Team/roles.idsl"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"no pattern",
+			"Include",
+			[]lexeme{
+				{typ: typeError, val: "Include not specified on line 1"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"unquoted",
+			"Include teams/*.idsl",
+			[]lexeme{
+				{typ: typeInclude},
+				{typ: typeError, val: "Include pattern must be double quoted on line 1"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"unclosed",
+			"Include \"teams/*.idsl\nUser Bob",
+			[]lexeme{
+				{typ: typeInclude},
+				{typ: typeError, val: "Unclosed include pattern on line 1"},
+				{typ: typeUser},
+				{typ: typeValue, val: "Bob"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"empty",
+			`Include ""`,
+			[]lexeme{
+				{typ: typeInclude},
+				{typ: typeError, val: "Empty include pattern on line 1"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"indented",
+			"Include \"teams/*.idsl\"\n\tUser Bob",
+			[]lexeme{
+				{typ: typeInclude},
+				{typ: typeValue, val: "teams/*.idsl"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeError, val: "Unexpected indentation on line 2"},
+				{typ: typeEOF},
+			},
+		)
+	})
+
+	t.Run("indentation", func(t *testing.T) {
+		lex(
+			t,
+			"spaces",
+			`Accounts Team Data
+    Assign
+        Role ReadOnly`,
+			[]lexeme{
+				{typ: typeAccounts},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "    "},
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "        "},
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeDedent},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"whitespace only and comment lines",
+			"Account 123456789012\n\tLabel1\n  \n\t\t// Indented comment\n\tLabel2",
+			[]lexeme{
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeComment, val: "// Indented comment"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeValue, val: "Label2"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"mixed on one line",
+			"Account 123456789012\n\t Label1",
+			[]lexeme{
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeError, val: "Mixed tabs and spaces in indentation on line 2"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"mixed between lines",
+			"Account 123456789012\n\tLabel1\nAccount 210987654321\n    Label2",
+			[]lexeme{
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeDedent},
+				{typ: typeAccount},
+				{typ: typeValue, val: "210987654321"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeError, val: "Mixed tabs and spaces in indentation on line 4"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"inconsistent spaces",
+			"Account 123456789012\n    Label1\n      Label2",
+			[]lexeme{
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "    "},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeError, val: "Inconsistent indentation on line 3"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"skipped level",
+			"Accounts Team Data\n\t\tAssign",
+			[]lexeme{
+				{typ: typeAccounts},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeError, val: "Skipped indentation level on line 2"},
 				{typ: typeEOF},
 			},
 		)
 
+		lex(
+			t,
+			"unexpected",
+			"Account 123456789012\n\tLabel1\n\t\tLabel2",
+			[]lexeme{
+				{typ: typeAccount},
+				{typ: typeValue, val: "123456789012"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Label1"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeError, val: "Unexpected indentation on line 3"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"top level",
+			"\tAccount 123456789012",
+			[]lexeme{
+				{typ: typeError, val: "Unexpected indentation on line 1"},
+				{typ: typeEOF},
+			},
+		)
 	})
+
+	t.Run("recovery", func(t *testing.T) {
+		lex(
+			t,
+			"carries on at next top level line",
+			`Account 123
+	Label1
+// Not a place to resume
+	Label2
+User Bob
+	Name "?"
+	Team Platform
+Group Good
+Hello`,
+			[]lexeme{
+				{typ: typeAccount},
+				{typ: typeError, val: "Bad length account ID on line 1 position 1"},
+				{typ: typeUser},
+				{typ: typeValue, val: "Bob"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeValue, val: "Name"},
+				{typ: typeError, val: "Invalid character ? on line 6"},
+				{typ: typeDedent},
+				{typ: typeGroup},
+				{typ: typeValue, val: "Good"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeError, val: "Unknown input 'Hello' on line 9"},
+				{typ: typeEOF},
+			},
+		)
+
+		lex(
+			t,
+			"nested contexts",
+			`Accounts Team Data
+	Assign
+		Role ?
+Assign
+	Role ReadOnly`,
+			[]lexeme{
+				{typ: typeAccounts},
+				{typ: typeValue, val: "Team"},
+				{typ: typeValue, val: "Data"},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t\t"},
+				{typ: typeRole},
+				{typ: typeError, val: "Missing selector on line 3 position 1"},
+				{typ: typeDedent},
+				{typ: typeDedent},
+				{typ: typeAssign},
+				{typ: typeEOL, val: "\n"},
+				{typ: typeIndent, val: "\t"},
+				{typ: typeRole},
+				{typ: typeValue, val: "ReadOnly"},
+				{typ: typeDedent},
+				{typ: typeEOF},
+			},
+		)
+	})
+}
+
+func TestLexErrors(t *testing.T) {
+	_, errs := lex("Account 1\nAccount 2\n\nUser ?")
+
+	want := []Error{
+		{Position{Offset: 8, Line: 1, Column: 9}, "Bad length account ID on line 1 position 1"},
+		{Position{Offset: 18, Line: 2, Column: 9}, "Bad length account ID on line 2 position 1"},
+		{Position{Offset: 26, Line: 4, Column: 6}, "Invalid user ID on line 4 position 1"},
+	}
+
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+
+	for i := range want {
+		if *errs[i] != want[i] {
+			t.Errorf("at pos %d, got %v %q, want %v %q", i, errs[i].Pos, errs[i].Msg, want[i].Pos, want[i].Msg)
+		}
+	}
 }
@@ -1,5 +1,7 @@
 package identitydsl
 
+import "fmt"
+
 type lexemeType int
 
 const (
@@ -7,29 +9,97 @@ const (
 	typeEOF
 	typeEOL
 	typeComment
-	typeSpace
 	typeValue
 	typeAccount
 	typeGroup
 	typeUser
 	typeRole
+	typeAssign
+	typeComma
+	typeIndent
+	typeDedent
+	typeAccounts
+	typeUsers
+	typeGroups
+	typeInclude
+	typeOU
+	typeMembers
+	typeProperty
+	typeDeny
+	typeExcept
 )
 
 type lexeme struct {
 	typ lexemeType
 	val string
+	pos Position // where the lexeme starts
+	end Position // where the lexeme ends, exclusive
 }
 
 type lexemes []lexeme
 
-func (l lexemes) currentLineNumber() int {
-	number := 1
+// describe names the lexeme for error messages.
+func (l lexeme) describe() string {
+	switch l.typ {
+	case typeEOF:
+		return "end of input"
+	case typeEOL:
+		return "line ending"
+	case typeComment:
+		return "comment"
+	case typeValue:
+		return fmt.Sprintf("'%s'", l.val)
+	case typeAccount:
+		return "Account"
+	case typeGroup:
+		return "Group"
+	case typeUser:
+		return "User"
+	case typeRole:
+		return "Role"
+	case typeAssign:
+		return "Assign"
+	case typeComma:
+		return "','"
+	case typeIndent:
+		return "indentation"
+	case typeDedent:
+		return "end of block"
+	case typeAccounts:
+		return "Accounts"
+	case typeUsers:
+		return "Users"
+	case typeGroups:
+		return "Groups"
+	case typeInclude:
+		return "Include"
+	case typeOU:
+		return "OU"
+	case typeMembers:
+		return "Members"
+	case typeProperty:
+		return l.val
+	case typeDeny:
+		return "Deny"
+	case typeExcept:
+		return "Except"
+	}
+
+	return "error"
+}
+
+// errors collects the error lexemes.
+func (l lexemes) errors() Errors {
+	var errs Errors
 
 	for i := range l {
-		if l[i].typ == typeEOL {
-			number += len(l[i].val)
+		if l[i].typ == typeError {
+			errs = append(errs, &Error{
+				Pos: l[i].pos,
+				Msg: l[i].val,
+			})
 		}
 	}
 
-	return number
+	return errs
 }
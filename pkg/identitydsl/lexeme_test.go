@@ -2,41 +2,77 @@ package identitydsl
 
 import "testing"
 
-func TestLexemesCurrentLineNumber(t *testing.T) {
+func TestLexemePositions(t *testing.T) {
 	t.Run("no lexemes", func(t *testing.T) {
-		l := lexemes{}
+		l := lexer{}
 
-		got, want := l.currentLineNumber(), 1
+		l.run(lexDSL)
+
+		got, want := l.items[0].pos, (Position{Offset: 0, Line: 1, Column: 1})
 
 		if got != want {
-			t.Errorf("got line number %d, want %d", got, want)
+			t.Errorf("got position %v, want %v", got, want)
 		}
 	})
 
-	t.Run("couple of newlines", func(t *testing.T) {
-		l := lexemes{
-			{
-				typ: typeComment,
-				val: "// Hi",
-			},
-			{
-				typ: typeEOL,
-				val: "\n",
-			},
-			{
-				typ: typeComment,
-				val: "// Hi",
-			},
-			{
-				typ: typeEOL,
-				val: "\n",
-			},
-		}
-
-		got, want := l.currentLineNumber(), 3
+	t.Run("couple of lines", func(t *testing.T) {
+		l := lexer{
+			input: "// Hi\r\nAccount 123456789012\n\t\"Label 1\"",
+		}
+
+		l.run(lexDSL)
+
+		want := []struct {
+			typ      lexemeType
+			pos, end Position
+		}{
+			{typeComment, Position{Offset: 0, Line: 1, Column: 1}, Position{Offset: 5, Line: 1, Column: 6}},
+			{typeEOL, Position{Offset: 5, Line: 1, Column: 6}, Position{Offset: 7, Line: 2, Column: 1}},
+			{typeAccount, Position{Offset: 7, Line: 2, Column: 1}, Position{Offset: 14, Line: 2, Column: 8}},
+			{typeValue, Position{Offset: 15, Line: 2, Column: 9}, Position{Offset: 27, Line: 2, Column: 21}},
+			{typeEOL, Position{Offset: 27, Line: 2, Column: 21}, Position{Offset: 28, Line: 3, Column: 1}},
+			{typeIndent, Position{Offset: 28, Line: 3, Column: 1}, Position{Offset: 29, Line: 3, Column: 2}},
+			{typeValue, Position{Offset: 30, Line: 3, Column: 3}, Position{Offset: 37, Line: 3, Column: 10}},
+			{typeDedent, Position{Offset: 38, Line: 3, Column: 11}, Position{Offset: 38, Line: 3, Column: 11}},
+			{typeEOF, Position{Offset: 38, Line: 3, Column: 11}, Position{Offset: 38, Line: 3, Column: 11}},
+		}
+
+		if len(l.items) != len(want) {
+			t.Fatalf("got %d lexemes, want %d", len(l.items), len(want))
+		}
+
+		for i := range want {
+			got := l.items[i]
+
+			if got.typ != want[i].typ || got.pos != want[i].pos || got.end != want[i].end {
+				t.Errorf("at pos %d, got %v %v-%v, want %v %v-%v", i, got.typ, got.pos, got.end, want[i].typ, want[i].pos, want[i].end)
+			}
+		}
+	})
+
+	t.Run("multibyte characters", func(t *testing.T) {
+		l := lexer{
+			input: "// Café\nUser Bob",
+		}
+
+		l.run(lexDSL)
+
+		got, want := l.items[3].pos, (Position{Offset: 14, Line: 2, Column: 6})
 
 		if got != want {
-			t.Errorf("got line number %d, want %d", got, want)
+			t.Errorf("got position %v, want %v", got, want)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, errs := lex("User Bob\n\tName \"?\"")
+
+		if len(errs) != 1 {
+			t.Fatalf("got %d errors, want 1", len(errs))
+		}
+
+		if want := (Position{Offset: 16, Line: 2, Column: 8}); errs[0].Pos != want {
+			t.Errorf("got position %v, want %v", errs[0].Pos, want)
 		}
 	})
 }
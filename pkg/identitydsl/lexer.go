@@ -9,11 +9,19 @@ import (
 const eof = rune(-1)
 
 type lexer struct {
+	file  string
 	input string
 	items lexemes
 	start int
 	pos   int
 	width int
+
+	startPos Position // position of start, tracked as lexemes are emitted
+
+	indent string      // whitespace making up one level of indentation
+	depth  int         // indentation level of the current line
+	scopes []stateFunc // lexes the lines at each level of indentation
+	body   stateFunc   // lexes lines indented beneath the current line
 }
 
 func (l *lexer) run(start stateFunc) {
@@ -23,6 +31,7 @@ func (l *lexer) run(start stateFunc) {
 }
 
 func (l *lexer) ignore() {
+	l.startPos = l.position(l.pos)
 	l.start = l.pos
 	l.width = 0
 }
@@ -109,18 +118,110 @@ func (l *lexer) value() string {
 }
 
 func (l *lexer) emit(typ lexemeType) {
+	end := l.position(l.pos)
 	l.items = append(l.items, lexeme{
 		typ: typ,
 		val: l.value(),
+		pos: l.position(l.start),
+		end: end,
 	})
 	l.start = l.pos
+	l.startPos = end
 	l.width = 0
 }
 
+// emitKeyword emits a keyword which has been accepted. Keywords are told apart
+// by type so the value is left empty.
+func (l *lexer) emitKeyword(typ lexemeType) {
+	pos := l.position(l.start)
+	l.ignore()
+	l.items = append(l.items, lexeme{
+		typ: typ,
+		pos: pos,
+		end: l.startPos,
+	})
+}
+
 func (l *lexer) errorf(format string, args ...interface{}) stateFunc {
+	pos := l.position(l.start)
 	l.items = append(l.items, lexeme{
-		typeError,
-		fmt.Sprintf(format, args...),
+		typ: typeError,
+		val: fmt.Sprintf(format, args...),
+		pos: pos,
+		end: pos,
 	})
-	return nil
+	return lexRecover
+}
+
+// position works out the position of an offset at or beyond start, counting
+// on from the position of start so each part of the input is only scanned
+// once. A carriage return and line feed pair is a single line ending.
+func (l *lexer) position(offset int) Position {
+	p := l.startPos
+
+	if p.Line == 0 {
+		p = Position{Line: 1, Column: 1, File: l.file}
+	}
+
+	for i, r := range l.input[l.start:offset] {
+		switch {
+		case r == '\n' && l.start+i > 0 && l.input[l.start+i-1] == '\r':
+		case r == '\r' || r == '\n':
+			p.Line++
+			p.Column = 0
+			fallthrough
+		default:
+			p.Column++
+		}
+	}
+
+	p.Offset = offset
+
+	return p
+}
+
+// line is the line number of the lexeme being lexed.
+func (l *lexer) line() int {
+	return l.position(l.start).Line
+}
+
+// indentation works out the indentation level from the leading whitespace of
+// a line. The first indented line decides whether the document is indented
+// with tabs or spaces, and for spaces how many make up one level.
+func (l *lexer) indentation(whitespace string) (int, string) {
+	if whitespace == "" {
+		return 0, ""
+	}
+
+	if strings.Contains(whitespace, " ") && strings.Contains(whitespace, "\t") {
+		return 0, "Mixed tabs and spaces in indentation"
+	}
+
+	if l.indent == "" {
+		l.indent = whitespace
+
+		if whitespace[0] == '\t' {
+			l.indent = "\t"
+		}
+	}
+
+	if whitespace[0] != l.indent[0] {
+		return 0, "Mixed tabs and spaces in indentation"
+	}
+
+	if len(whitespace)%len(l.indent) != 0 {
+		return 0, "Inconsistent indentation"
+	}
+
+	return len(whitespace) / len(l.indent), ""
+}
+
+// dedent emits a dedent for each level of indentation being closed, until
+// the given depth is reached.
+func (l *lexer) dedent(depth int) {
+	for l.depth > depth {
+		l.depth--
+		l.scopes = l.scopes[:l.depth]
+		l.emit(typeDedent)
+	}
 }
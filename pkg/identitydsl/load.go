@@ -0,0 +1,184 @@
+package identitydsl
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Extension is the extension of the files loaded from a directory.
+const Extension = ".idsl"
+
+// Load reads a project made up of one or more files, merging them into one
+// document. The path is either a file, or a directory whose files ending in
+// Extension are loaded in order of their paths, subdirectories included.
+//
+// Include blocks are replaced by the blocks of the files they name, so they
+// are merged in the order written. A file is only ever loaded once, however
+// many times it is included. As with Parse, the document holds everything
+// which could be parsed, and the error lists every problem found with the
+// file it was found in.
+func Load(path string) (*Document, error) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return LoadSource(path, string(data))
+	}
+
+	var files []string
+
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(file) == Extension {
+			files = append(files, file)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s files found in %s", Extension, path)
+	}
+
+	l := newLoader()
+
+	for _, file := range files {
+		if l.loaded[filepath.Clean(file)] {
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		l.doc.Blocks = append(l.doc.Blocks, l.file(file, string(data))...)
+	}
+
+	return l.result()
+}
+
+// LoadSource loads a project from a single file whose contents are given
+// rather than read, such as a file being edited. The files it includes are
+// read as usual.
+func LoadSource(file, source string) (*Document, error) {
+	l := newLoader()
+
+	l.doc.Blocks = l.file(file, source)
+
+	return l.result()
+}
+
+type loader struct {
+	doc    *Document
+	loaded map[string]bool
+	errors Errors
+}
+
+func newLoader() *loader {
+	return &loader{
+		doc:    &Document{},
+		loaded: map[string]bool{},
+	}
+}
+
+func (l *loader) errorf(pos Position, format string, args ...interface{}) {
+	l.errors = append(l.errors, &Error{
+		Pos: pos,
+		Msg: fmt.Sprintf(format, args...),
+	})
+}
+
+// file parses a file, returning its blocks with those of the files it
+// includes in place of its Include blocks.
+func (l *loader) file(file, source string) []Block {
+	file = filepath.Clean(file)
+	l.loaded[file] = true
+
+	doc, err := ParseFile(file, source)
+
+	if err != nil {
+		l.errors = append(l.errors, err.(Errors)...)
+	}
+
+	l.doc.Comments = append(l.doc.Comments, doc.Comments...)
+
+	var blocks []Block
+
+	for _, b := range doc.Blocks {
+		if include, ok := b.(*IncludeBlock); ok {
+			blocks = append(blocks, l.include(file, include)...)
+			continue
+		}
+
+		blocks = append(blocks, b)
+	}
+
+	return blocks
+}
+
+// include loads the files matched by the pattern of an Include block, which
+// is relative to the directory of the file it is written in.
+func (l *loader) include(from string, b *IncludeBlock) []Block {
+	matches, err := filepath.Glob(relativeTo(from, b.Pattern.Text))
+
+	if err != nil {
+		l.errorf(b.Pattern.Start, "Invalid include pattern '%s' on line %d", b.Pattern.Text, b.Start.Line)
+		return nil
+	}
+
+	var blocks []Block
+
+	found := false
+
+	for _, file := range matches {
+		if info, err := os.Stat(file); err == nil && info.IsDir() {
+			continue
+		}
+
+		found = true
+
+		if l.loaded[file] {
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			l.errorf(b.Pattern.Start, "Cannot read included file '%s' on line %d", file, b.Start.Line)
+			continue
+		}
+
+		blocks = append(blocks, l.file(file, string(data))...)
+	}
+
+	if !found {
+		l.errorf(b.Pattern.Start, "Include '%s' matches no files on line %d", b.Pattern.Text, b.Start.Line)
+	}
+
+	return blocks
+}
+
+func (l *loader) result() (*Document, error) {
+	sort.SliceStable(l.errors, func(i, j int) bool {
+		return l.errors[i].Pos.before(l.errors[j].Pos)
+	})
+
+	return l.doc, l.errors.Err()
+}
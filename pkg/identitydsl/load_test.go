@@ -0,0 +1,171 @@
+package identitydsl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	// project writes the files to a temporary directory, returning it.
+	project := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+
+		dir := t.TempDir()
+
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return dir
+	}
+
+	// problems lists the errors with the files they were found in, relative
+	// to the project directory.
+	problems := func(t *testing.T, dir string, err error) string {
+		t.Helper()
+
+		errs, ok := err.(Errors)
+
+		if !ok && err != nil {
+			t.Fatalf("got error %v, want Errors", err)
+		}
+
+		var lines []string
+
+		for _, e := range errs {
+			file, _ := filepath.Rel(dir, e.Pos.File)
+			lines = append(lines, filepath.ToSlash(file)+": "+strings.ReplaceAll(e.Msg, dir+string(filepath.Separator), ""))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	t.Run("include", func(t *testing.T) {
+		dir := project(t, map[string]string{
+			"main.idsl": `Include "accounts/*.idsl"
+Group DBA
+Include "roles.idsl"
+Assign
+	Account Production
+	Role ReadOnly
+	Group DBA`,
+			"accounts/a.idsl": "Account 111111111111\n\tProduction",
+			"accounts/b.idsl": "Account 222222222222\n\tProduction\n// Includes are only loaded once\nInclude \"../roles.idsl\"",
+			"roles.idsl":      "Role ReadOnly",
+		})
+
+		doc, err := Load(filepath.Join(dir, "main.idsl"))
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := "Account(111111111111) labels(Production); " +
+			"Account(222222222222) labels(Production); " +
+			"Role(ReadOnly) policies(); " +
+			"Group(DBA); " +
+			"Assign Account(Production) Role(ReadOnly) Group(DBA)"
+
+		if got := outline(doc.Blocks); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+
+		if got := doc.Blocks[2].Pos().File; got != filepath.Join(dir, "roles.idsl") {
+			t.Errorf("got role in %s, want roles.idsl", got)
+		}
+
+		if len(doc.Comments) != 1 || doc.Comments[0].Start.Line != 3 {
+			t.Errorf("got comments %v, want the one on line 3", doc.Comments)
+		}
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		dir := project(t, map[string]string{
+			"roles.idsl":        "Role ReadOnly",
+			"teams/data.idsl":   "Group DBA\nUser Alice",
+			"accounts.idsl":     "Account 111111111111",
+			"notes.txt":         "Not part of the project",
+			"teams/assign.idsl": "Assign\n\tAccount 111111111111\n\tRole ReadOnly\n\tUser Alice",
+		})
+
+		doc, err := Load(dir)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := "Account(111111111111); " +
+			"Role(ReadOnly) policies(); " +
+			"Assign Account(111111111111) Role(ReadOnly) User(Alice); " +
+			"Group(DBA); User(Alice)"
+
+		if got := outline(doc.Blocks); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		dir := project(t, map[string]string{"notes.txt": ""})
+
+		if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "no .idsl files found") {
+			t.Errorf("got error %v, want no files found", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		dir := project(t, map[string]string{
+			"main.idsl": `Include "people.idsl"
+Include "missing/*.idsl"
+Include "[.idsl"
+Group DBA
+User ?`,
+			"people.idsl": "User Bob\nGroup DBA\nAccount 123",
+		})
+
+		doc, err := Load(filepath.Join(dir, "main.idsl"))
+
+		want := `main.idsl: Include 'missing/*.idsl' matches no files on line 2
+main.idsl: Invalid include pattern '[.idsl' on line 3
+main.idsl: Invalid user ID on line 5 position 1
+people.idsl: Bad length account ID on line 3 position 1`
+
+		if got := problems(t, dir, err); got != want {
+			t.Errorf("got errors:\n%s\nwant:\n%s", got, want)
+		}
+
+		err = Validate(doc)
+
+		want = "main.idsl: Duplicate Group DBA on line 4, already declared in people.idsl on line 2"
+
+		if got := problems(t, dir, err); got != want {
+			t.Errorf("got errors:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("source", func(t *testing.T) {
+		dir := project(t, map[string]string{
+			"main.idsl":   "Include \"people.idsl\"\nUser Alice",
+			"people.idsl": "User Bob",
+		})
+
+		doc, err := LoadSource(filepath.Join(dir, "main.idsl"), "Include \"people.idsl\"\nGroup Edited")
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := outline(doc.Blocks), "User(Bob); Group(Edited)"; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	})
+}
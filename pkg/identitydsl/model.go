@@ -0,0 +1,447 @@
+package identitydsl
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Model is the identity graph described by a document, holding every entity
+// declared in the order they were declared.
+type Model struct {
+	Accounts []*Account
+	Users    []*User
+	Groups   []*Group
+	Roles    []*Role
+	OUs      []*OU
+}
+
+// Account is an AWS account which roles can be assigned in.
+type Account struct {
+	ID string // the 12 digit account ID
+	OU string // the OU the account is in, if any
+	Attributes
+	Pos Position // where the account was declared
+}
+
+// User is a person in the Identity Center identity store.
+type User struct {
+	Name string // the user name, also used to select the user
+	Attributes
+	Pos Position // where the user was declared
+}
+
+// Group is a group of users in the Identity Center identity store.
+type Group struct {
+	Name string // the display name, also used to select the group
+	Attributes
+	Pos     Position // where the group was declared
+	Members []Member // in the order the users were declared
+}
+
+// Member is a user in a group, along with the selectors on the Members lines
+// of the group which picked them.
+type Member struct {
+	User      *User
+	Selectors []Selector
+}
+
+// Member finds the member with the given user name.
+func (g *Group) Member(name string) *Member {
+	for i := range g.Members {
+		if g.Members[i].User.Name == name {
+			return &g.Members[i]
+		}
+	}
+
+	return nil
+}
+
+// OU is an organizational unit which accounts are organised in, beneath an
+// optional parent OU.
+type OU struct {
+	Name   string // the OU name, also used to select accounts within it
+	Parent string // the OU above, if any
+	Attributes
+	Pos Position // where the OU was declared
+}
+
+// Role is a permission set, along with the policies attached to it.
+type Role struct {
+	Name     string   // the permission set name
+	Policies []Policy // defaults to a single policy named after the role
+	Pos      Position // where the role was declared
+
+	// Properties of the permission set, left empty when not set.
+	Description     string
+	SessionDuration time.Duration
+	RelayState      string
+	Inline          *InlinePolicy
+	Boundary        Policy // the permissions boundary, if any
+}
+
+// InlinePolicy is a policy document embedded in a permission set, kept in a
+// JSON file.
+type InlinePolicy struct {
+	Path     string   // the file, relative to the file naming it
+	Pos      Position // where the file was named
+	Document string   // the policy, once read by ReadPolicies
+}
+
+// Policy is the name or ARN of a policy attached to a role.
+type Policy string
+
+// Label is a keyless string associated with an entity.
+type Label string
+
+// Tag is a key value pair associated with an entity.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Attributes are the labels and tags decorating an account, user, group or
+// OU.
+type Attributes struct {
+	Labels []Label
+	Tags   []Tag
+
+	// ous are, for an account in an OU, the OU and each OU above it, worked
+	// out by NewModel so a selector can pick accounts by OU without the model.
+	// They are never set for other entities.
+	ous []string
+}
+
+// HasLabel reports whether the entity has the label.
+func (a Attributes) HasLabel(label string) bool {
+	for i := range a.Labels {
+		if string(a.Labels[i]) == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tag returns the value of the tag with the given key, if the entity has it.
+func (a Attributes) Tag(key string) (string, bool) {
+	for i := range a.Tags {
+		if a.Tags[i].Key == key {
+			return a.Tags[i].Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Assignment gives a user or group a role in an account. Exactly one of User
+// and Group is set.
+type Assignment struct {
+	Account *Account
+	User    *User
+	Group   *Group
+	Role    *Role
+
+	// Sources are the Assign blocks which made the assignment, as worked out
+	// by Expand.
+	Sources []Source
+}
+
+// Source explains how an Assign block made an assignment.
+type Source struct {
+	Assign *AssignBlock
+
+	// Contexts enclose the Assign block, outermost first.
+	Contexts []*ContextBlock
+
+	// Matches are the selectors which picked the account, principal and role
+	// of the assignment, from the contexts and then the Assign block.
+	Matches []Match
+}
+
+// Match is a selector which picked an entity of a kind for an assignment.
+type Match struct {
+	Kind     Kind
+	Selector Selector
+}
+
+// PrincipalType is USER or GROUP, as Identity Center calls them.
+func (a Assignment) PrincipalType() string {
+	if a.User != nil {
+		return "USER"
+	}
+
+	return "GROUP"
+}
+
+// PrincipalName is the name of the user or group.
+func (a Assignment) PrincipalName() string {
+	if a.User != nil {
+		return a.User.Name
+	}
+
+	return a.Group.Name
+}
+
+// Includes reports whether the assignment is made to the user, or to a group
+// the user is a member of.
+func (a Assignment) Includes(user string) bool {
+	if a.User != nil {
+		return a.User.Name == user
+	}
+
+	return a.Group.Member(user) != nil
+}
+
+// NewModel builds the model from a parsed document. Each entity declared in
+// an Account, User, Group, OU or Role block gets its own copy of the labels,
+// tags or policies listed in the block.
+func NewModel(doc *Document) *Model {
+	m := &Model{}
+
+	// Members are picked once every user is known.
+	members := map[*Group][]*Selection{}
+
+	for _, b := range doc.Blocks {
+		switch b := b.(type) {
+		case *EntityBlock:
+			for _, id := range b.IDs {
+				attributes := newAttributes(b)
+
+				switch b.Kind {
+				case KindAccount:
+					m.Accounts = append(m.Accounts, &Account{id.Text, b.property("OU"), attributes, id.Start})
+				case KindUser:
+					m.Users = append(m.Users, &User{id.Text, attributes, id.Start})
+				case KindGroup:
+					g := &Group{Name: id.Text, Attributes: attributes, Pos: id.Start}
+					m.Groups = append(m.Groups, g)
+					members[g] = b.Members
+				case KindOU:
+					m.OUs = append(m.OUs, &OU{id.Text, b.property("Parent"), attributes, id.Start})
+				}
+			}
+		case *RoleBlock:
+			for _, name := range b.Names {
+				r := &Role{
+					Name: name.Text,
+					Pos:  name.Start,
+				}
+
+				for _, policy := range b.Policies {
+					r.Policies = append(r.Policies, Policy(policy.Text))
+				}
+
+				if len(r.Policies) == 0 {
+					r.Policies = []Policy{Policy(name.Text)}
+				}
+
+				for _, p := range b.Properties {
+					switch p.Key.Text {
+					case "Description":
+						r.Description = p.Value.Text
+					case "SessionDuration":
+						r.SessionDuration, _ = parseSessionDuration(p.Value.Text)
+					case "RelayState":
+						r.RelayState = p.Value.Text
+					case "Boundary":
+						r.Boundary = Policy(p.Value.Text)
+					case "Inline":
+						r.Inline = &InlinePolicy{
+							Path: relativeTo(p.Value.Start.File, p.Value.Text),
+							Pos:  p.Value.Start,
+						}
+					}
+				}
+
+				m.Roles = append(m.Roles, r)
+			}
+		}
+	}
+
+	for _, a := range m.Accounts {
+		if a.OU != "" {
+			a.ous = m.ancestry(a.OU)
+		}
+	}
+
+	for _, g := range m.Groups {
+		for _, u := range m.Users {
+			member := Member{User: u}
+
+			for _, s := range members[g] {
+				for _, sel := range s.Selectors {
+					if sel.Matches(u.Name, u.Attributes) {
+						member.Selectors = append(member.Selectors, sel)
+					}
+				}
+			}
+
+			if len(member.Selectors) > 0 {
+				g.Members = append(g.Members, member)
+			}
+		}
+	}
+
+	return m
+}
+
+// ancestry lists an OU followed by each OU above it, stopping at an OU which
+// is undeclared or has already been seen.
+func (m *Model) ancestry(name string) []string {
+	var out []string
+
+	for name != "" && !slices.Contains(out, name) {
+		out = append(out, name)
+
+		ou := m.OU(name)
+
+		if ou == nil {
+			break
+		}
+
+		name = ou.Parent
+	}
+
+	return out
+}
+
+// property returns the value first given to a property of an entity, or an
+// empty string when it is not given.
+func (b *EntityBlock) property(key string) string {
+	for _, p := range b.Properties {
+		if p.Key.Text == key {
+			return p.Value.Text
+		}
+	}
+
+	return ""
+}
+
+func newAttributes(b *EntityBlock) Attributes {
+	var a Attributes
+
+	for _, label := range b.Labels {
+		a.Labels = append(a.Labels, Label(label.Text))
+	}
+
+	for _, tag := range b.Tags {
+		a.Tags = append(a.Tags, Tag{tag.Key.Text, tag.Value.Text})
+	}
+
+	return a
+}
+
+// isoDuration matches an ISO-8601 duration of hours, minutes and seconds,
+// such as PT1H30M.
+var isoDuration = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?$`)
+
+// parseSessionDuration parses a session duration, written either as an
+// ISO-8601 duration such as PT8H or in the short form such as 8h or 1h30m.
+func parseSessionDuration(s string) (time.Duration, error) {
+	if !strings.HasPrefix(s, "PT") {
+		return time.ParseDuration(s)
+	}
+
+	match := isoDuration.FindStringSubmatch(s)
+
+	if match == nil || s == "PT" {
+		return 0, errors.New("invalid ISO-8601 duration")
+	}
+
+	var d time.Duration
+
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if match[i+1] == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(match[i+1])
+
+		if err != nil {
+			return 0, err
+		}
+
+		d += time.Duration(n) * unit
+	}
+
+	return d, nil
+}
+
+// FormatSessionDuration writes a session duration in the ISO-8601 form
+// Identity Center takes, such as PT1H30M.
+func FormatSessionDuration(d time.Duration) string {
+	s := "PT"
+
+	if h := int(d / time.Hour); h > 0 {
+		s += strconv.Itoa(h) + "H"
+	}
+
+	if m := int(d % time.Hour / time.Minute); m > 0 {
+		s += strconv.Itoa(m) + "M"
+	}
+
+	if sec := int(d % time.Minute / time.Second); sec > 0 {
+		s += strconv.Itoa(sec) + "S"
+	}
+
+	return s
+}
+
+// Account finds the account with the given ID.
+func (m *Model) Account(id string) *Account {
+	for _, a := range m.Accounts {
+		if a.ID == id {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// User finds the user with the given name.
+func (m *Model) User(name string) *User {
+	for _, u := range m.Users {
+		if u.Name == name {
+			return u
+		}
+	}
+
+	return nil
+}
+
+// Group finds the group with the given name.
+func (m *Model) Group(name string) *Group {
+	for _, g := range m.Groups {
+		if g.Name == name {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// OU finds the OU with the given name.
+func (m *Model) OU(name string) *OU {
+	for _, ou := range m.OUs {
+		if ou.Name == name {
+			return ou
+		}
+	}
+
+	return nil
+}
+
+// Role finds the role with the given name.
+func (m *Model) Role(name string) *Role {
+	for _, r := range m.Roles {
+		if r.Name == name {
+			return r
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package identitydsl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewModel(t *testing.T) {
+	doc, err := Parse(`Account 123456789012, 098765432109
+	Owner Legal
+	Production
+
+User Bob.Smith
+	"Full Name" "Bob Smith"
+
+Group Developers
+
+Group Admins
+	Members Alice, Bob.Smith
+	Members Team Platform
+
+User Alice
+	Team Platform
+
+Role ReadOnly
+Role Admin
+	arn1
+	arn2
+	SessionDuration PT2H30M
+	Boundary /security/Boundary
+
+Assign
+	Account Owner Legal
+	Role ReadOnly
+	Group Developers`)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := NewModel(doc)
+
+	t.Run("accounts", func(t *testing.T) {
+		if len(m.Accounts) != 2 {
+			t.Fatalf("got %d accounts, want 2", len(m.Accounts))
+		}
+
+		for i, id := range []string{"123456789012", "098765432109"} {
+			a := m.Accounts[i]
+
+			if a.ID != id {
+				t.Errorf("got account %s, want %s", a.ID, id)
+			}
+
+			if !a.HasLabel("Production") {
+				t.Errorf("account %s is missing label Production", a.ID)
+			}
+
+			if v, ok := a.Tag("Owner"); !ok || v != "Legal" {
+				t.Errorf("account %s has Owner tag %q, want Legal", a.ID, v)
+			}
+		}
+
+		if m.Accounts[1].Pos.Line != 1 || m.Accounts[1].Pos.Column != 23 {
+			t.Errorf("got position %v, want 1:23", m.Accounts[1].Pos)
+		}
+
+		// Each account has its own copy of the attributes
+		m.Accounts[0].Labels[0] = "Changed"
+
+		if !m.Accounts[1].HasLabel("Production") {
+			t.Errorf("attributes are shared between accounts")
+		}
+	})
+
+	t.Run("users and groups", func(t *testing.T) {
+		if u := m.User("Bob.Smith"); u == nil || !reflect.DeepEqual(u.Tags, []Tag{{"Full Name", "Bob Smith"}}) {
+			t.Errorf("got user %+v", u)
+		}
+
+		if g := m.Group("Developers"); g == nil || len(g.Labels) != 0 || len(g.Tags) != 0 {
+			t.Errorf("got group %+v", g)
+		}
+
+		if m.User("Nobody") != nil {
+			t.Errorf("found user which was not declared")
+		}
+	})
+
+	t.Run("members", func(t *testing.T) {
+		g := m.Group("Admins")
+
+		var got []string
+
+		for _, member := range g.Members {
+			got = append(got, member.User.Name+" "+selectorList(member.Selectors))
+		}
+
+		if want := []string{"Bob.Smith Bob.Smith", "Alice Alice, Team Platform"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got members %q, want %q", got, want)
+		}
+
+		a := Assignment{Group: g}
+
+		if !a.Includes("Alice") || a.Includes("Nobody") {
+			t.Errorf("got wrong members for assignment to a group")
+		}
+	})
+
+	t.Run("roles", func(t *testing.T) {
+		if r := m.Role("ReadOnly"); r == nil || !reflect.DeepEqual(r.Policies, []Policy{"ReadOnly"}) {
+			t.Errorf("got role %+v, want policy named after role", r)
+		}
+
+		if r := m.Role("Admin"); r == nil || !reflect.DeepEqual(r.Policies, []Policy{"arn1", "arn2"}) {
+			t.Errorf("got role %+v", r)
+		}
+
+		if d := m.Role("Admin").SessionDuration; d != 150*time.Minute {
+			t.Errorf("got session duration %v, want 2h30m", d)
+		}
+
+		if b := m.Role("Admin").Boundary; b != "/security/Boundary" {
+			t.Errorf("got boundary %s, want /security/Boundary", b)
+		}
+	})
+
+	t.Run("assignment principal", func(t *testing.T) {
+		a := Assignment{
+			Account: m.Accounts[0],
+			Group:   m.Group("Developers"),
+			Role:    m.Role("ReadOnly"),
+		}
+
+		if a.PrincipalType() != "GROUP" || a.PrincipalName() != "Developers" {
+			t.Errorf("got principal %s %s", a.PrincipalType(), a.PrincipalName())
+		}
+
+		a.Group, a.User = nil, m.User("Bob.Smith")
+
+		if a.PrincipalType() != "USER" || a.PrincipalName() != "Bob.Smith" {
+			t.Errorf("got principal %s %s", a.PrincipalType(), a.PrincipalName())
+		}
+	})
+}
+
+func TestFormatSessionDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		time.Hour:                   "PT1H",
+		12 * time.Hour:              "PT12H",
+		90 * time.Minute:            "PT1H30M",
+		time.Hour + 30*time.Second:  "PT1H30S",
+		2*time.Hour + 5*time.Minute: "PT2H5M",
+	} {
+		if got := FormatSessionDuration(d); got != want {
+			t.Errorf("FormatSessionDuration(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,473 @@
+package identitydsl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Parse parses a DSL document. The document is returned even when there are
+// errors, holding every block which could be parsed, and the error lists
+// every problem found in the order they appear in the input.
+func Parse(input string) (*Document, error) {
+	return ParseFile("", input)
+}
+
+// ParseFile parses a DSL document read from a file, recording the file in
+// every position. Include blocks are left in place; Load reads the files
+// they name.
+func ParseFile(file, input string) (*Document, error) {
+	items, errs := lexFile(file, input)
+
+	p := parser{
+		items: items,
+	}
+
+	doc := p.document()
+
+	errs = append(errs, p.errors...)
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.before(errs[j].Pos)
+	})
+
+	return doc, errs.Err()
+}
+
+type parser struct {
+	items    lexemes
+	pos      int
+	depth    int
+	comments []*Comment
+	errors   Errors
+}
+
+// bail is panicked with to abandon the statement being parsed.
+type bail struct{}
+
+func (p *parser) document() *Document {
+	doc := &Document{}
+
+	for {
+		switch p.peek().typ {
+		case typeEOF:
+			doc.Comments = p.comments
+			return doc
+		case typeEOL:
+			p.next()
+		default:
+			if b := p.statement(); b != nil {
+				doc.Blocks = append(doc.Blocks, b)
+			}
+		}
+	}
+}
+
+// statement parses a top level block. When the block cannot be parsed, the
+// lexemes are skipped up to the next top level block and nil is returned.
+func (p *parser) statement() (b Block) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bail); !ok {
+				panic(r)
+			}
+
+			p.sync()
+
+			b = nil
+		}
+	}()
+
+	switch item := p.peek(); item.typ {
+	case typeAccount:
+		return p.entity(KindAccount)
+	case typeUser:
+		return p.entity(KindUser)
+	case typeGroup:
+		return p.entity(KindGroup)
+	case typeRole:
+		return p.role()
+	case typeOU:
+		return p.entity(KindOU)
+	case typeAssign:
+		return p.assign()
+	case typeDeny:
+		return p.deny()
+	case typeAccounts:
+		return p.context(KindAccount)
+	case typeUsers:
+		return p.context(KindUser)
+	case typeGroups:
+		return p.context(KindGroup)
+	case typeInclude:
+		return p.include()
+	default:
+		p.unexpected(p.next())
+	}
+
+	return nil
+}
+
+func (p *parser) entity(kind Kind) *EntityBlock {
+	b := &EntityBlock{
+		Start: p.next().pos,
+		Kind:  kind,
+		IDs:   p.values(),
+	}
+
+	p.lineEnd()
+
+	p.block(func() {
+		if p.peek().typ == typeMembers {
+			b.Members = append(b.Members, &Selection{
+				Start:     p.next().pos,
+				Kind:      KindUser,
+				Selectors: p.selectors(),
+			})
+
+			p.lineEnd()
+
+			return
+		}
+
+		if item := p.peek(); item.typ == typeProperty {
+			p.next()
+
+			values := p.values()
+
+			if len(values) != 1 {
+				p.unexpected(p.peek())
+			}
+
+			b.Properties = append(b.Properties, Pair{Value{item.pos, item.val}, values[0]})
+
+			p.lineEnd()
+
+			return
+		}
+
+		switch values := p.values(); len(values) {
+		case 1:
+			b.Labels = append(b.Labels, values[0])
+		case 2:
+			b.Tags = append(b.Tags, Pair{values[0], values[1]})
+		default:
+			p.unexpected(p.peek())
+		}
+
+		p.lineEnd()
+	})
+
+	return b
+}
+
+func (p *parser) role() *RoleBlock {
+	b := &RoleBlock{
+		Start: p.next().pos,
+		Names: p.values(),
+	}
+
+	p.lineEnd()
+
+	p.block(func() {
+		if item := p.peek(); item.typ == typeProperty {
+			p.next()
+
+			values := p.values()
+
+			if len(values) != 1 {
+				p.unexpected(p.peek())
+			}
+
+			b.Properties = append(b.Properties, Pair{Value{item.pos, item.val}, values[0]})
+
+			p.lineEnd()
+
+			return
+		}
+
+		values := p.values()
+
+		if len(values) != 1 {
+			p.unexpected(p.peek())
+		}
+
+		b.Policies = append(b.Policies, values[0])
+
+		p.lineEnd()
+	})
+
+	return b
+}
+
+func (p *parser) include() *IncludeBlock {
+	b := &IncludeBlock{
+		Start: p.next().pos,
+	}
+
+	item := p.next()
+
+	if item.typ != typeValue {
+		p.unexpected(item)
+	}
+
+	b.Pattern = Value{
+		Start: item.pos,
+		Text:  item.val,
+	}
+
+	p.lineEnd()
+
+	return b
+}
+
+func (p *parser) assign() *AssignBlock {
+	b := &AssignBlock{
+		Start: p.next().pos,
+	}
+
+	p.lineEnd()
+
+	p.block(func() {
+		b.Selections = append(b.Selections, p.selection())
+	})
+
+	return b
+}
+
+func (p *parser) deny() *DenyBlock {
+	b := &DenyBlock{
+		Start: p.next().pos,
+	}
+
+	p.lineEnd()
+
+	p.block(func() {
+		if p.peek().typ == typeExcept {
+			start := p.next().pos
+
+			s := p.selection()
+			s.Start = start
+
+			b.Exceptions = append(b.Exceptions, s)
+			return
+		}
+
+		b.Selections = append(b.Selections, p.selection())
+	})
+
+	return b
+}
+
+func (p *parser) selection() *Selection {
+	item := p.next()
+
+	s := &Selection{
+		Start: item.pos,
+	}
+
+	switch item.typ {
+	case typeAccount:
+		s.Kind = KindAccount
+	case typeUser:
+		s.Kind = KindUser
+	case typeGroup:
+		s.Kind = KindGroup
+	case typeRole:
+		s.Kind = KindRole
+	default:
+		p.unexpected(item)
+	}
+
+	s.Selectors = p.selectors()
+
+	p.lineEnd()
+
+	return s
+}
+
+func (p *parser) context(kind Kind) *ContextBlock {
+	b := &ContextBlock{
+		Start: p.next().pos,
+		Kind:  kind,
+	}
+
+	b.Selectors = p.selectors()
+
+	p.lineEnd()
+
+	p.block(func() {
+		switch p.peek().typ {
+		case typeAssign:
+			b.Blocks = append(b.Blocks, p.assign())
+		case typeAccounts:
+			b.Blocks = append(b.Blocks, p.context(KindAccount))
+		case typeUsers:
+			b.Blocks = append(b.Blocks, p.context(KindUser))
+		case typeGroups:
+			b.Blocks = append(b.Blocks, p.context(KindGroup))
+		default:
+			p.unexpected(p.next())
+		}
+	})
+
+	return b
+}
+
+// selectors parses a comma delimited list of selectors.
+func (p *parser) selectors() []Selector {
+	var selectors []Selector
+
+	for {
+		var ou *Position
+
+		if item := p.peek(); item.typ == typeOU {
+			p.next()
+			ou = &item.pos
+		}
+
+		switch values := p.values(); {
+		case ou != nil && len(values) == 1:
+			selectors = append(selectors, Selector{Value: values[0], OU: ou})
+		case ou != nil:
+			p.unexpected(p.peek())
+		case len(values) == 1:
+			selectors = append(selectors, Selector{Value: values[0]})
+		case len(values) == 2:
+			selectors = append(selectors, Selector{Key: &values[0], Value: values[1]})
+		default:
+			p.unexpected(p.peek())
+		}
+
+		if p.peek().typ != typeComma {
+			return selectors
+		}
+
+		p.next()
+	}
+}
+
+// block parses the lines indented beneath a header, if there are any.
+func (p *parser) block(line func()) {
+	p.skipLineEndings()
+
+	if p.peek().typ != typeIndent {
+		return
+	}
+
+	p.next()
+
+	for {
+		p.skipLineEndings()
+
+		switch p.peek().typ {
+		case typeDedent:
+			p.next()
+			return
+		case typeEOF:
+			return
+		}
+
+		line()
+	}
+}
+
+// values parses a run of values.
+func (p *parser) values() []Value {
+	var values []Value
+
+	for p.peek().typ == typeValue {
+		item := p.next()
+
+		values = append(values, Value{
+			Start: item.pos,
+			Text:  item.val,
+		})
+	}
+
+	return values
+}
+
+// lineEnd expects nothing more on the current line.
+func (p *parser) lineEnd() {
+	switch item := p.peek(); item.typ {
+	case typeEOL:
+		p.next()
+	case typeEOF, typeDedent:
+	default:
+		p.unexpected(item)
+	}
+}
+
+func (p *parser) skipLineEndings() {
+	for p.peek().typ == typeEOL {
+		p.next()
+	}
+}
+
+// peek returns the next lexeme without consuming it, collecting any comments
+// on the way as they have no bearing on the structure of the document.
+func (p *parser) peek() lexeme {
+	for p.items[p.pos].typ == typeComment {
+		item := p.items[p.pos]
+
+		p.comments = append(p.comments, &Comment{
+			Start: item.pos,
+			Text:  item.val,
+		})
+
+		p.pos++
+	}
+
+	return p.items[p.pos]
+}
+
+// next consumes the next lexeme, keeping track of the indentation level.
+func (p *parser) next() lexeme {
+	item := p.peek()
+
+	switch item.typ {
+	case typeEOF:
+		return item
+	case typeIndent:
+		p.depth++
+	case typeDedent:
+		p.depth--
+	}
+
+	p.pos++
+
+	return item
+}
+
+// unexpected abandons the current statement, reporting the lexeme found
+// unless it is an error the lexer has already reported.
+func (p *parser) unexpected(item lexeme) {
+	if item.typ != typeError {
+		p.errors = append(p.errors, &Error{
+			Pos: item.pos,
+			Msg: fmt.Sprintf("Unexpected %s on line %d", item.describe(), item.pos.Line),
+		})
+	}
+
+	panic(bail{})
+}
+
+// sync skips lexemes until the start of the next top level statement.
+func (p *parser) sync() {
+	for {
+		item := p.peek()
+
+		if item.typ == typeEOF {
+			return
+		}
+
+		if p.depth == 0 {
+			switch item.typ {
+			case typeAccount, typeUser, typeGroup, typeRole, typeAssign, typeAccounts, typeUsers, typeGroups, typeInclude, typeOU, typeDeny:
+				return
+			}
+		}
+
+		p.next()
+	}
+}
@@ -0,0 +1,316 @@
+package identitydsl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// outline summarises blocks for comparison, leaving out positions.
+func outline(blocks []Block) string {
+	values := func(values []Value) string {
+		texts := make([]string, len(values))
+
+		for i := range values {
+			texts[i] = values[i].Text
+		}
+
+		return strings.Join(texts, ",")
+	}
+
+	selectors := func(selectors []Selector) string {
+		texts := make([]string, len(selectors))
+
+		for i, s := range selectors {
+			texts[i] = s.Value.Text
+
+			if s.IsTag() {
+				texts[i] = s.Key.Text + "=" + s.Value.Text
+			}
+
+			if s.IsOU() {
+				texts[i] = "ou:" + s.Value.Text
+			}
+		}
+
+		return strings.Join(texts, ",")
+	}
+
+	var lines []string
+
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *EntityBlock:
+			line := fmt.Sprintf("%s(%s)", b.Kind, values(b.IDs))
+
+			if len(b.Labels) > 0 {
+				line += " labels(" + values(b.Labels) + ")"
+			}
+
+			for _, t := range b.Tags {
+				line += fmt.Sprintf(" tag(%s=%s)", t.Key.Text, t.Value.Text)
+			}
+
+			for _, s := range b.Members {
+				line += " members(" + selectors(s.Selectors) + ")"
+			}
+
+			for _, p := range b.Properties {
+				line += fmt.Sprintf(" %s(%s)", p.Key.Text, p.Value.Text)
+			}
+
+			lines = append(lines, line)
+		case *RoleBlock:
+			line := fmt.Sprintf("Role(%s) policies(%s)", values(b.Names), values(b.Policies))
+
+			for _, p := range b.Properties {
+				line += fmt.Sprintf(" %s(%s)", p.Key.Text, p.Value.Text)
+			}
+
+			lines = append(lines, line)
+		case *AssignBlock:
+			line := "Assign"
+
+			for _, s := range b.Selections {
+				line += fmt.Sprintf(" %s(%s)", s.Kind, selectors(s.Selectors))
+			}
+
+			lines = append(lines, line)
+		case *DenyBlock:
+			line := "Deny"
+
+			for _, s := range b.Selections {
+				line += fmt.Sprintf(" %s(%s)", s.Kind, selectors(s.Selectors))
+			}
+
+			for _, s := range b.Exceptions {
+				line += fmt.Sprintf(" except %s(%s)", s.Kind, selectors(s.Selectors))
+			}
+
+			lines = append(lines, line)
+		case *ContextBlock:
+			lines = append(lines, fmt.Sprintf("%ss(%s) {%s}", b.Kind, selectors(b.Selectors), outline(b.Blocks)))
+		case *IncludeBlock:
+			lines = append(lines, fmt.Sprintf("Include(%s)", b.Pattern.Text))
+		}
+	}
+
+	return strings.Join(lines, "; ")
+}
+
+func TestParse(t *testing.T) {
+	parse := func(t *testing.T, name, input, want string) {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Parse(input)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := outline(doc.Blocks); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	parse(t, "empty", "", "")
+
+	parse(
+		t,
+		"entities",
+		`Account 123456789012, 098765432109
+	Owner Legal
+	Label1
+	"Label 3"
+
+User Bob.Smith
+	"Full Name" "Bob Smith"
+
+Group FooDevelopers, FooTesters`,
+		"Account(123456789012,098765432109) labels(Label1,Label 3) tag(Owner=Legal); "+
+			"User(Bob.Smith) tag(Full Name=Bob Smith); "+
+			"Group(FooDevelopers,FooTesters)",
+	)
+
+	parse(
+		t,
+		"roles",
+		`Role ReadOnly
+Role Admin, Support
+	OtherPolicy
+	AnotherPolicy`,
+		"Role(ReadOnly) policies(); Role(Admin,Support) policies(OtherPolicy,AnotherPolicy)",
+	)
+
+	parse(
+		t,
+		"role properties",
+		`Role ReadOnly
+	SessionDuration PT8H
+	Policy
+	Description "Read only"`,
+		"Role(ReadOnly) policies(Policy) SessionDuration(PT8H) Description(Read only)",
+	)
+
+	parse(
+		t,
+		"organizational units",
+		`OU Production
+	Parent Workloads
+Account 123456789012
+	OU Production
+	"OU" Engineering
+Assign
+	Account OU Workloads, "OU" Engineering`,
+		"OU(Production) Parent(Workloads); "+
+			"Account(123456789012) tag(OU=Engineering) OU(Production); "+
+			"Assign Account(ou:Workloads,OU=Engineering)",
+	)
+
+	parse(
+		t,
+		"members",
+		`Group DBA
+	Members Alice, Team Data
+	Production
+	Members Bob`,
+		"Group(DBA) labels(Production) members(Alice,Team=Data) members(Bob)",
+	)
+
+	parse(
+		t,
+		"include",
+		`Include "teams/*.idsl"
+User Bob`,
+		"Include(teams/*.idsl); User(Bob)",
+	)
+
+	parse(
+		t,
+		"assign",
+		`Assign
+	Account Team Data, Snowflake, Bar
+	Role DBAReadOnly
+	Group DBA
+	User Alice, Bob`,
+		"Assign Account(Team=Data,Snowflake,Bar) Role(DBAReadOnly) Group(DBA) User(Alice,Bob)",
+	)
+
+	parse(
+		t,
+		"deny",
+		`Deny
+	Account Production
+	Except Group BreakGlass, Team Security
+	Role AdministratorAccess
+Deny
+	Role Billing`,
+		"Deny Account(Production) Role(AdministratorAccess) except Group(BreakGlass,Team=Security); Deny Role(Billing)",
+	)
+
+	parse(
+		t,
+		"nested contexts",
+		`Accounts Team Data
+	Accounts Environment Dev
+
+		Assign
+			Role ReadWrite
+			Group DataTeamDeveloper, DataTeamOperations
+	Accounts Environment Production
+		Assign
+			Role ReadOnly
+			Group DataTeamDeveloper
+
+		Assign
+			Role ReadWrite
+			Group DataTeamOperations
+Assign
+	Role Other`,
+		"Accounts(Team=Data) {"+
+			"Accounts(Environment=Dev) {Assign Role(ReadWrite) Group(DataTeamDeveloper,DataTeamOperations)}; "+
+			"Accounts(Environment=Production) {Assign Role(ReadOnly) Group(DataTeamDeveloper); Assign Role(ReadWrite) Group(DataTeamOperations)}"+
+			"}; Assign Role(Other)",
+	)
+
+	t.Run("comments", func(t *testing.T) {
+		doc, err := Parse("// One\nAccount 123456789012\n\t// Two\n\tLabel1\n// Three")
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := outline(doc.Blocks), "Account(123456789012) labels(Label1)"; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+
+		want := []Comment{
+			{Position{Offset: 0, Line: 1, Column: 1}, "// One"},
+			{Position{Offset: 29, Line: 3, Column: 2}, "// Two"},
+			{Position{Offset: 44, Line: 5, Column: 1}, "// Three"},
+		}
+
+		if len(doc.Comments) != len(want) {
+			t.Fatalf("got %d comments, want %d", len(doc.Comments), len(want))
+		}
+
+		for i := range want {
+			if *doc.Comments[i] != want[i] {
+				t.Errorf("at pos %d, got %v, want %v", i, *doc.Comments[i], want[i])
+			}
+		}
+	})
+
+	t.Run("positions", func(t *testing.T) {
+		doc, err := Parse("\nAssign\n\tRole ReadOnly")
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		b := doc.Blocks[0].(*AssignBlock)
+
+		if want := (Position{Offset: 1, Line: 2, Column: 1}); b.Pos() != want {
+			t.Errorf("got block position %v, want %v", b.Pos(), want)
+		}
+
+		if want := (Position{Offset: 14, Line: 3, Column: 7}); b.Selections[0].Selectors[0].Value.Start != want {
+			t.Errorf("got selector position %v, want %v", b.Selections[0].Selectors[0].Value.Start, want)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		doc, err := Parse(`Account 123
+	Label1
+Group Good
+Accounts Team Data
+	Assign
+		Role ?
+User Fine`)
+
+		if got, want := outline(doc.Blocks), "Group(Good); User(Fine)"; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+
+		errs, ok := err.(Errors)
+
+		if !ok {
+			t.Fatalf("got error %v, want Errors", err)
+		}
+
+		want := []string{
+			"Bad length account ID on line 1 position 1",
+			"Missing selector on line 6 position 1",
+		}
+
+		if len(errs) != len(want) {
+			t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+		}
+
+		for i := range want {
+			if errs[i].Msg != want[i] {
+				t.Errorf("at pos %d, got %q, want %q", i, errs[i].Msg, want[i])
+			}
+		}
+	})
+}
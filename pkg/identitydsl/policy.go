@@ -0,0 +1,339 @@
+package identitydsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Versions of the IAM policy language a policy document can be written in.
+var policyVersions = []string{"2012-10-17", "2008-10-17"}
+
+// Elements allowed at the top of a policy document and in its statements.
+var (
+	policyElements    = []string{"Version", "Id", "Statement"}
+	statementElements = []string{"Sid", "Effect", "Action", "NotAction", "Resource", "NotResource", "Condition"}
+)
+
+// Limits IAM puts on the name and path of a policy.
+const (
+	maxPolicyName = 128
+	maxPolicyPath = 512
+)
+
+// policyName and policyPath match the names and paths IAM allows for a
+// policy.
+var (
+	policyName = regexp.MustCompile(`^[\w+=,.@-]+$`)
+	policyPath = regexp.MustCompile(`^/(?:[\x21-\x7E]+/)?$`)
+)
+
+// Partitions are the AWS partitions a policy ARN can be in.
+var Partitions = []string{"aws", "aws-us-gov", "aws-cn"}
+
+// IsARN reports whether the policy is given by its ARN rather than by name.
+func (p Policy) IsARN() bool {
+	return strings.HasPrefix(string(p), "arn:")
+}
+
+// AWSManaged reports whether the policy is an AWS managed policy, given by an
+// ARN in the aws account rather than a numbered one.
+func (p Policy) AWSManaged() bool {
+	parts := p.arn()
+	return parts != nil && parts[4] == "aws"
+}
+
+// Partition is the partition of a policy given by ARN, or empty for a policy
+// given by name.
+func (p Policy) Partition() string {
+	if parts := p.arn(); parts != nil {
+		return parts[1]
+	}
+
+	return ""
+}
+
+// Name is the name of the policy, without its path.
+func (p Policy) Name() string {
+	resource := p.resource()
+	return resource[strings.LastIndex(resource, "/")+1:]
+}
+
+// Path is the path of the policy, which begins and ends with a slash and is
+// the root path unless one is given.
+func (p Policy) Path() string {
+	resource := p.resource()
+	return "/" + strings.TrimPrefix(resource[:strings.LastIndex(resource, "/")+1], "/")
+}
+
+// arn splits the ARN of a policy given by ARN into its six segments.
+func (p Policy) arn() []string {
+	if !p.IsARN() {
+		return nil
+	}
+
+	parts := strings.SplitN(string(p), ":", 6)
+
+	if len(parts) < 6 {
+		return nil
+	}
+
+	return parts
+}
+
+// resource is the name of the policy with its path before it.
+func (p Policy) resource() string {
+	if parts := p.arn(); parts != nil {
+		return strings.TrimPrefix(parts[5], "policy")
+	}
+
+	return string(p)
+}
+
+// check checks a policy is either the ARN of an IAM policy in a known
+// partition or a customer managed policy name with an optional path,
+// returning the problem found if not.
+func (p Policy) check() string {
+	if !p.IsARN() {
+		if strings.Contains(string(p), ":") || (strings.Contains(string(p), "/") && !validPolicyPath(string(p))) {
+			return fmt.Sprintf("Invalid policy path '%s'", p)
+		}
+
+		return ""
+	}
+
+	parts := strings.Split(string(p), ":")
+
+	switch {
+	case len(parts) != 6:
+		return fmt.Sprintf("Invalid policy ARN '%s'", p)
+	case !slices.Contains(Partitions, parts[1]):
+		return fmt.Sprintf("Unknown partition '%s' in policy ARN '%s'", parts[1], p)
+	case parts[2] != "iam":
+		return fmt.Sprintf("Service '%s' in policy ARN '%s' is not iam", parts[2], p)
+	case parts[3] != "":
+		return fmt.Sprintf("Region '%s' given in policy ARN '%s'", parts[3], p)
+	case parts[4] != "aws" && !isAccountID(parts[4]):
+		return fmt.Sprintf("Invalid account '%s' in policy ARN '%s'", parts[4], p)
+	case !strings.HasPrefix(parts[5], "policy/") || !validPolicyPath(strings.TrimPrefix(parts[5], "policy")):
+		return fmt.Sprintf("Invalid resource '%s' in policy ARN '%s'", parts[5], p)
+	}
+
+	return ""
+}
+
+// resolve checks IAM could resolve the policy, which must be a well formed
+// ARN, or a name and path IAM allows, returning the problem found if not.
+func (p Policy) resolve() string {
+	if problem := p.check(); problem != "" {
+		return problem
+	}
+
+	name, path := p.Name(), p.Path()
+
+	switch {
+	case len(name) > maxPolicyName:
+		return fmt.Sprintf("Policy name '%s' is longer than %d characters", name, maxPolicyName)
+	case !policyName.MatchString(name):
+		return fmt.Sprintf("Invalid policy name '%s'", name)
+	case len(path) > maxPolicyPath:
+		return fmt.Sprintf("Policy path '%s' is longer than %d characters", path, maxPolicyPath)
+	case !policyPath.MatchString(path):
+		return fmt.Sprintf("Invalid policy path '%s'", path)
+	}
+
+	return ""
+}
+
+// validPolicyPath reports whether a policy name with its path before it, such
+// as /security/Boundary, has a path beginning with a slash and no empty
+// segments.
+func validPolicyPath(path string) bool {
+	return strings.HasPrefix(path, "/") && !strings.Contains(path, "//") && !strings.HasSuffix(path, "/")
+}
+
+// isAccountID reports whether s is a 12 digit account ID.
+func isAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReadPolicies reads the inline policy of each role, setting its Document.
+// Every file which cannot be read or is not an IAM policy is reported, once
+// for each line naming it.
+func (m *Model) ReadPolicies() error {
+	var errs Errors
+
+	reported := map[Position]bool{}
+
+	for _, r := range m.Roles {
+		if r.Inline == nil {
+			continue
+		}
+
+		document, err := readPolicy(r.Inline.Path)
+
+		if err != nil {
+			if !reported[r.Inline.Pos] {
+				reported[r.Inline.Pos] = true
+				errs = append(errs, policyError(r.Inline.Pos, r.Inline.Path, err))
+			}
+
+			continue
+		}
+
+		r.Inline.Document = document
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.before(errs[j].Pos)
+	})
+
+	return errs.Err()
+}
+
+func policyError(pos Position, path string, err error) *Error {
+	return &Error{
+		Pos: pos,
+		Msg: fmt.Sprintf("Inline policy '%s' %v on line %d", path, err, pos.Line),
+	}
+}
+
+// relativeTo resolves a path written in a file against the directory of the
+// file.
+func relativeTo(file, path string) string {
+	if file == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(filepath.Dir(file), path)
+}
+
+// readPolicy reads an IAM policy document, returning it without insignificant
+// whitespace. The error completes a sentence beginning with the file name.
+func readPolicy(path string) (string, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return "", errors.New("cannot be read")
+	}
+
+	var document map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &document); err != nil {
+		return "", errors.New("is not valid JSON")
+	}
+
+	if err := checkPolicy(document); err != nil {
+		return "", err
+	}
+
+	var compact bytes.Buffer
+
+	if err := json.Compact(&compact, data); err != nil {
+		return "", errors.New("is not valid JSON")
+	}
+
+	return compact.String(), nil
+}
+
+// checkPolicy checks a policy document has the structure IAM requires.
+func checkPolicy(document map[string]json.RawMessage) error {
+	if err := checkElements(document, policyElements); err != nil {
+		return err
+	}
+
+	var version string
+
+	if err := json.Unmarshal(document["Version"], &version); err != nil || !slices.Contains(policyVersions, version) {
+		return errors.New("has no valid Version")
+	}
+
+	raw, ok := document["Statement"]
+
+	if !ok {
+		return errors.New("has no Statement")
+	}
+
+	var statements []map[string]json.RawMessage
+
+	if err := json.Unmarshal(raw, &statements); err != nil {
+		var statement map[string]json.RawMessage
+
+		if err := json.Unmarshal(raw, &statement); err != nil {
+			return errors.New("has a Statement which is not an object or list of objects")
+		}
+
+		statements = append(statements, statement)
+	}
+
+	if len(statements) == 0 {
+		return errors.New("has no Statement")
+	}
+
+	for i, s := range statements {
+		if err := checkStatement(s); err != nil {
+			return fmt.Errorf("statement %d %v", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// checkStatement checks a statement of a policy document, which needs an
+// effect, actions and resources.
+func checkStatement(statement map[string]json.RawMessage) error {
+	if err := checkElements(statement, statementElements); err != nil {
+		return err
+	}
+
+	var effect string
+
+	if err := json.Unmarshal(statement["Effect"], &effect); err != nil || (effect != "Allow" && effect != "Deny") {
+		return errors.New("has no valid Effect")
+	}
+
+	if statement["Action"] == nil && statement["NotAction"] == nil {
+		return errors.New("has no Action")
+	}
+
+	if statement["Resource"] == nil && statement["NotResource"] == nil {
+		return errors.New("has no Resource")
+	}
+
+	return nil
+}
+
+func checkElements(object map[string]json.RawMessage, allowed []string) error {
+	keys := make([]string, 0, len(object))
+
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !slices.Contains(allowed, key) {
+			return fmt.Errorf("has unexpected element '%s'", key)
+		}
+	}
+
+	return nil
+}
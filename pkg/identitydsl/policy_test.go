@@ -0,0 +1,158 @@
+package identitydsl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadPolicies(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"policies/extra.json": `{
+	"Version": "2012-10-17",
+	"Statement": {
+		"Effect": "Allow",
+		"Action": "s3:GetObject",
+		"Resource": "*"
+	}
+}`,
+		"policies/broken.json":    `{"Version": "2012-10-17",`,
+		"policies/version.json":   `{"Version": "2020-01-01", "Statement": []}`,
+		"policies/empty.json":     `{"Version": "2012-10-17", "Statement": []}`,
+		"policies/effect.json":    `{"Version": "2012-10-17", "Statement": [{"Effect": "Maybe", "Action": "*", "Resource": "*"}]}`,
+		"policies/action.json":    `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}, {"Effect": "Deny", "Resource": "*"}]}`,
+		"policies/resource.json":  `{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "NotAction": "*"}]}`,
+		"policies/principal.json": `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "*", "Resource": "*"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// problems lists the messages of the errors, with paths relative to the
+	// project directory.
+	problems := func(err error) string {
+		var lines []string
+
+		for _, e := range err.(Errors) {
+			lines = append(lines, strings.ReplaceAll(e.Msg, dir+string(filepath.Separator), ""))
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		doc, err := LoadSource(filepath.Join(dir, "main.idsl"), "Role ReadOnly, Auditor\n\tInline policies/extra.json\nRole Admin")
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := Validate(doc); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		m := NewModel(doc)
+
+		if err := m.ReadPolicies(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}}`
+
+		for _, name := range []string{"ReadOnly", "Auditor"} {
+			if got := m.Role(name).Inline; got == nil || got.Document != want || got.Path != filepath.Join(dir, "policies/extra.json") {
+				t.Errorf("got %s inline policy %+v, want %s", name, got, want)
+			}
+		}
+
+		if got := m.Role("Admin").Inline; got != nil {
+			t.Errorf("got Admin inline policy %+v, want none", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		doc, err := LoadSource(filepath.Join(dir, "main.idsl"), `Role A, B
+	Inline policies/missing.json
+Role C
+	Inline policies/broken.json
+Role D
+	Inline policies/version.json
+Role E
+	Inline policies/empty.json
+Role F
+	Inline policies/effect.json
+Role G
+	Inline policies/action.json
+Role H
+	Inline policies/resource.json
+Role I
+	Inline policies/principal.json`)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := `Inline policy 'policies/missing.json' cannot be read on line 2
+Inline policy 'policies/broken.json' is not valid JSON on line 4
+Inline policy 'policies/version.json' has no valid Version on line 6
+Inline policy 'policies/empty.json' has no Statement on line 8
+Inline policy 'policies/effect.json' statement 1 has no valid Effect on line 10
+Inline policy 'policies/action.json' statement 2 has no Action on line 12
+Inline policy 'policies/resource.json' statement 1 has no Resource on line 14
+Inline policy 'policies/principal.json' statement 1 has unexpected element 'Principal' on line 16`
+
+		if got := problems(Validate(doc)); got != want {
+			t.Errorf("got errors:\n%s\nwant:\n%s", got, want)
+		}
+
+		if got := problems(NewModel(doc).ReadPolicies()); got != want {
+			t.Errorf("got errors:\n%s\nwant:\n%s", got, want)
+		}
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	for _, test := range []struct {
+		policy     Policy
+		name, path string
+		awsManaged bool
+		partition  string
+	}{
+		{"ReadOnly", "ReadOnly", "/", false, ""},
+		{"/team/Custom", "Custom", "/team/", false, ""},
+		{"arn:aws:iam::aws:policy/AmazonEC2FullAccess", "AmazonEC2FullAccess", "/", true, "aws"},
+		{"arn:aws:iam::aws:policy/job-function/ViewOnlyAccess", "ViewOnlyAccess", "/job-function/", true, "aws"},
+		{"arn:aws-us-gov:iam::123456789012:policy/team/Custom", "Custom", "/team/", false, "aws-us-gov"},
+		{"arn:aws-cn:iam::aws:policy/ReadOnlyAccess", "ReadOnlyAccess", "/", true, "aws-cn"},
+	} {
+		if got := test.policy.Name(); got != test.name {
+			t.Errorf("got %s name %s, want %s", test.policy, got, test.name)
+		}
+
+		if got := test.policy.Path(); got != test.path {
+			t.Errorf("got %s path %s, want %s", test.policy, got, test.path)
+		}
+
+		if got := test.policy.AWSManaged(); got != test.awsManaged {
+			t.Errorf("got %s AWS managed %v, want %v", test.policy, got, test.awsManaged)
+		}
+
+		if got := test.policy.Partition(); got != test.partition {
+			t.Errorf("got %s partition %s, want %s", test.policy, got, test.partition)
+		}
+
+		if problem := test.policy.check(); problem != "" {
+			t.Errorf("got %s problem %s, want none", test.policy, problem)
+		}
+	}
+}
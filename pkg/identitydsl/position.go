@@ -0,0 +1,29 @@
+package identitydsl
+
+import "fmt"
+
+// Position is a location in the input.
+type Position struct {
+	Offset int    // byte offset, starting at 0
+	Line   int    // line number, starting at 1
+	Column int    // column number in characters, starting at 1
+	File   string // file the input was read from, if any
+}
+
+func (p Position) String() string {
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// before reports whether p comes before q, ordering positions in different
+// files by the name of the file.
+func (p Position) before(q Position) bool {
+	if p.File != q.File {
+		return p.File < q.File
+	}
+
+	return p.Offset < q.Offset
+}
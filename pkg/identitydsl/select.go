@@ -0,0 +1,91 @@
+package identitydsl
+
+import (
+	"slices"
+	"strings"
+)
+
+// Start is where the selector starts.
+func (s Selector) Start() Position {
+	if s.IsOU() {
+		return *s.OU
+	}
+
+	if s.IsTag() {
+		return s.Key.Start
+	}
+
+	return s.Value.Start
+}
+
+// String writes the selector as it would appear in a document.
+func (s Selector) String() string {
+	if s.IsOU() {
+		return "OU " + quote(s.Value.Text)
+	}
+
+	if s.IsTag() {
+		key := quote(s.Key.Text)
+
+		// A bare OU key would pick accounts by OU instead.
+		if key == "OU" {
+			key = `"OU"`
+		}
+
+		return key + " " + quote(s.Value.Text)
+	}
+
+	return quote(s.Value.Text)
+}
+
+// quote wraps a value in double quotes when it contains spaces.
+func quote(value string) string {
+	if strings.Contains(value, " ") {
+		return `"` + value + `"`
+	}
+
+	return value
+}
+
+// Matches reports whether the selector picks an entity with the given ID and
+// attributes. Selecting accounts by OU picks those in any OU beneath it too.
+func (s Selector) Matches(id string, a Attributes) bool {
+	if s.IsOU() {
+		return slices.Contains(a.ous, s.Value.Text)
+	}
+
+	if s.IsTag() {
+		return a.hasTag(Tag{s.Key.Text, s.Value.Text})
+	}
+
+	return s.Value.Text == id || a.HasLabel(s.Value.Text)
+}
+
+// defines reports whether the model has an entity of the given kind picked by
+// the selector.
+func (m *Model) defines(kind Kind, s Selector) bool {
+	switch kind {
+	case KindAccount:
+		for _, a := range m.Accounts {
+			if s.Matches(a.ID, a.Attributes) {
+				return true
+			}
+		}
+	case KindUser:
+		for _, u := range m.Users {
+			if s.Matches(u.Name, u.Attributes) {
+				return true
+			}
+		}
+	case KindGroup:
+		for _, g := range m.Groups {
+			if s.Matches(g.Name, g.Attributes) {
+				return true
+			}
+		}
+	case KindRole:
+		return !s.IsTag() && m.Role(s.Value.Text) != nil
+	}
+
+	return false
+}
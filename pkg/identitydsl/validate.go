@@ -0,0 +1,390 @@
+package identitydsl
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+)
+
+// maxPermissionSetName is the longest name Identity Center allows for a
+// permission set.
+const maxPermissionSetName = 32
+
+// Limits Identity Center puts on the properties of a permission set.
+const (
+	minSessionDuration = time.Hour
+	maxSessionDuration = 12 * time.Hour
+	maxDescription     = 700
+	maxRelayState      = 240
+)
+
+// Validate checks a document for logical errors, returning every one found in
+// the order they appear in the input. Once everything selected is known to be
+// declared, the assignments are expanded to find selectors which pick nothing.
+// Assignments forbidden by Deny blocks are left to Enforce, so a document
+// breaking its guardrails can still be explained and queried.
+func Validate(doc *Document) error {
+	v := validator{
+		model: NewModel(doc),
+	}
+
+	v.duplicates()
+	v.labels(doc.Blocks)
+	v.ous(doc.Blocks)
+	v.members(doc.Blocks)
+	v.roles(doc.Blocks)
+	v.partitions(doc.Blocks)
+	v.properties(doc.Blocks)
+	v.blocks(doc.Blocks, selected{})
+
+	// Selectors picking nothing are only looked for once every selector is
+	// known to refer to something declared, to avoid reporting them twice.
+	if len(v.errors) == 0 {
+		if _, err := Expand(doc); err != nil {
+			v.errors = append(v.errors, err.(Errors)...)
+		}
+	}
+
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		return v.errors[i].Pos.before(v.errors[j].Pos)
+	})
+
+	return v.errors.Err()
+}
+
+type validator struct {
+	model  *Model
+	errors Errors
+}
+
+// selected records which kinds of entity have been selected by the contexts
+// enclosing a block.
+type selected map[Kind]bool
+
+func (v *validator) errorf(pos Position, format string, args ...interface{}) {
+	v.errors = append(v.errors, &Error{
+		Pos: pos,
+		Msg: fmt.Sprintf(format, args...),
+	})
+}
+
+// duplicates reports entities declared more than once.
+func (v *validator) duplicates() {
+	seen := map[Kind]map[string]Position{}
+
+	check := func(kind Kind, id string, pos Position) {
+		if seen[kind] == nil {
+			seen[kind] = map[string]Position{}
+		}
+
+		if first, ok := seen[kind][id]; ok {
+			if first.File != pos.File {
+				v.errorf(pos, "Duplicate %s %s on line %d, already declared in %s on line %d", kind, id, pos.Line, first.File, first.Line)
+				return
+			}
+
+			v.errorf(pos, "Duplicate %s %s on line %d, already declared on line %d", kind, id, pos.Line, first.Line)
+			return
+		}
+
+		seen[kind][id] = pos
+	}
+
+	for _, a := range v.model.Accounts {
+		check(KindAccount, a.ID, a.Pos)
+	}
+
+	for _, u := range v.model.Users {
+		check(KindUser, u.Name, u.Pos)
+	}
+
+	for _, g := range v.model.Groups {
+		check(KindGroup, g.Name, g.Pos)
+	}
+
+	for _, r := range v.model.Roles {
+		check(KindRole, r.Name, r.Pos)
+	}
+
+	for _, ou := range v.model.OUs {
+		check(KindOU, ou.Name, ou.Pos)
+	}
+}
+
+// labels reports labels which are the same as the ID of an entity of the same
+// kind, as selecting by either would be ambiguous.
+func (v *validator) labels(blocks []Block) {
+	for _, b := range blocks {
+		b, ok := b.(*EntityBlock)
+
+		if !ok {
+			continue
+		}
+
+		for _, label := range b.Labels {
+			var clash bool
+
+			switch b.Kind {
+			case KindAccount:
+				clash = v.model.Account(label.Text) != nil
+			case KindUser:
+				clash = v.model.User(label.Text) != nil
+			case KindGroup:
+				clash = v.model.Group(label.Text) != nil
+			case KindOU:
+				clash = v.model.OU(label.Text) != nil
+			}
+
+			if clash {
+				v.errorf(label.Start, "Label '%s' matches the ID of a declared %s on line %d", label.Text, b.Kind, label.Start.Line)
+			}
+		}
+	}
+}
+
+// ous reports OU properties of accounts and Parent properties of OUs which
+// name an undeclared OU or are given more than once, and OUs beneath
+// themselves.
+func (v *validator) ous(blocks []Block) {
+	for _, b := range blocks {
+		b, ok := b.(*EntityBlock)
+
+		if !ok {
+			continue
+		}
+
+		for i, p := range b.Properties {
+			if i > 0 {
+				v.errorf(p.Key.Start, "%s given more than once on line %d", p.Key.Text, p.Key.Start.Line)
+				continue
+			}
+
+			if v.model.OU(p.Value.Text) == nil {
+				v.errorf(p.Value.Start, "Undefined OU '%s' on line %d", p.Value.Text, p.Value.Start.Line)
+			}
+		}
+	}
+
+	for _, ou := range v.model.OUs {
+		if ou.Parent != "" && slices.Contains(v.model.ancestry(ou.Parent), ou.Name) {
+			v.errorf(ou.Pos, "OU %s is beneath itself on line %d", ou.Name, ou.Pos.Line)
+		}
+	}
+}
+
+// members reports Members selectors which do not pick any declared user.
+func (v *validator) members(blocks []Block) {
+	for _, b := range blocks {
+		b, ok := b.(*EntityBlock)
+
+		if !ok {
+			continue
+		}
+
+		for _, s := range b.Members {
+			v.selectors(KindUser, s.Selectors)
+
+			for _, sel := range s.Selectors {
+				if sel.IsTag() && !v.model.defines(KindUser, sel) {
+					v.errorf(sel.Key.Start, "Members selector '%s' matches nothing on line %d", sel, sel.Key.Start.Line)
+				}
+			}
+		}
+	}
+}
+
+// roles reports roles which cannot be made into a permission set, and the
+// policies and boundaries they name which IAM could not resolve.
+func (v *validator) roles(blocks []Block) {
+	for _, r := range v.model.Roles {
+		if len(r.Name) > maxPermissionSetName {
+			v.errorf(r.Pos, "Role name '%s' is longer than %d characters on line %d", r.Name, maxPermissionSetName, r.Pos.Line)
+		}
+	}
+
+	for _, b := range blocks {
+		b, ok := b.(*RoleBlock)
+
+		if !ok {
+			continue
+		}
+
+		policies := slices.Clone(b.Policies)
+
+		for _, p := range b.Properties {
+			if p.Key.Text == "Boundary" {
+				policies = append(policies, p.Value)
+			}
+		}
+
+		for _, p := range policies {
+			if problem := Policy(p.Text).resolve(); problem != "" {
+				v.errorf(p.Start, "%s on line %d", problem, p.Start.Line)
+			}
+		}
+	}
+}
+
+// partitions reports policy ARNs in a different partition to the first one
+// given, as an Identity Center instance only lives in one partition.
+func (v *validator) partitions(blocks []Block) {
+	var first *Value
+
+	for _, b := range blocks {
+		b, ok := b.(*RoleBlock)
+
+		if !ok {
+			continue
+		}
+
+		policies := slices.Clone(b.Policies)
+
+		for _, p := range b.Properties {
+			if p.Key.Text == "Boundary" {
+				policies = append(policies, p.Value)
+			}
+		}
+
+		sort.SliceStable(policies, func(i, j int) bool {
+			return policies[i].Start.Offset < policies[j].Start.Offset
+		})
+
+		for i := range policies {
+			partition := Policy(policies[i].Text).Partition()
+
+			switch {
+			case partition == "":
+			case first == nil:
+				first = &policies[i]
+			case partition != Policy(first.Text).Partition():
+				v.errorf(policies[i].Start, "Policy ARN '%s' is in the %s partition on line %d, but the ARN on line %d is in %s", policies[i].Text, partition, policies[i].Start.Line, first.Start.Line, Policy(first.Text).Partition())
+			}
+		}
+	}
+}
+
+// properties reports role properties which are given more than once or
+// which Identity Center would reject, including inline policy files which
+// cannot be read or are not IAM policies.
+func (v *validator) properties(blocks []Block) {
+	for _, b := range blocks {
+		b, ok := b.(*RoleBlock)
+
+		if !ok {
+			continue
+		}
+
+		seen := map[string]bool{}
+
+		for _, p := range b.Properties {
+			key, value, line := p.Key.Text, p.Value.Text, p.Key.Start.Line
+
+			if seen[key] {
+				v.errorf(p.Key.Start, "%s given more than once on line %d", key, line)
+				continue
+			}
+
+			seen[key] = true
+
+			switch key {
+			case "SessionDuration":
+				d, err := parseSessionDuration(value)
+
+				switch {
+				case err != nil:
+					v.errorf(p.Value.Start, "Invalid SessionDuration '%s' on line %d", value, line)
+				case d < minSessionDuration || d > maxSessionDuration:
+					v.errorf(p.Value.Start, "SessionDuration '%s' is not between 1 and 12 hours on line %d", value, line)
+				}
+			case "RelayState":
+				if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+					v.errorf(p.Value.Start, "RelayState '%s' is not a URL on line %d", value, line)
+				} else if len(value) > maxRelayState {
+					v.errorf(p.Value.Start, "RelayState is longer than %d characters on line %d", maxRelayState, line)
+				}
+			case "Description":
+				if len(value) > maxDescription {
+					v.errorf(p.Value.Start, "Description is longer than %d characters on line %d", maxDescription, line)
+				}
+			case "Inline":
+				path := relativeTo(p.Value.Start.File, value)
+
+				if _, err := readPolicy(path); err != nil {
+					v.errors = append(v.errors, policyError(p.Value.Start, path, err))
+				}
+			}
+		}
+	}
+}
+
+// blocks reports selectors which do not pick any declared entity, and Assign
+// blocks missing a selection of accounts, principals or roles once the
+// enclosing contexts are taken into account.
+func (v *validator) blocks(blocks []Block, outer selected) {
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *ContextBlock:
+			v.selectors(b.Kind, b.Selectors)
+
+			inner := selected{b.Kind: true}
+
+			for kind := range outer {
+				inner[kind] = true
+			}
+
+			v.blocks(b.Blocks, inner)
+		case *DenyBlock:
+			for _, s := range b.Selections {
+				v.selectors(s.Kind, s.Selectors)
+			}
+
+			for _, s := range b.Exceptions {
+				v.selectors(s.Kind, s.Selectors)
+			}
+
+			if len(b.Selections) == 0 {
+				v.errorf(b.Start, "Deny on line %d does not select anything", b.Start.Line)
+			}
+		case *AssignBlock:
+			has := selected{}
+
+			for kind := range outer {
+				has[kind] = true
+			}
+
+			for _, s := range b.Selections {
+				v.selectors(s.Kind, s.Selectors)
+				has[s.Kind] = true
+			}
+
+			if !has[KindAccount] {
+				v.errorf(b.Start, "Assign on line %d does not select any accounts", b.Start.Line)
+			}
+
+			if !has[KindUser] && !has[KindGroup] {
+				v.errorf(b.Start, "Assign on line %d does not select any users or groups", b.Start.Line)
+			}
+
+			if !has[KindRole] {
+				v.errorf(b.Start, "Assign on line %d does not select any roles", b.Start.Line)
+			}
+		}
+	}
+}
+
+func (v *validator) selectors(kind Kind, selectors []Selector) {
+	for _, s := range selectors {
+		switch {
+		case kind == KindRole && s.IsTag():
+			v.errorf(s.Key.Start, "Roles cannot be selected by tag on line %d", s.Key.Start.Line)
+		case s.IsOU():
+			if v.model.OU(s.Value.Text) == nil {
+				v.errorf(s.Value.Start, "Undefined OU '%s' on line %d", s.Value.Text, s.Value.Start.Line)
+			}
+		case !s.IsTag() && !v.model.defines(kind, s):
+			v.errorf(s.Value.Start, "Undefined %s '%s' on line %d", kind, s.Value.Text, s.Value.Start.Line)
+		}
+	}
+}
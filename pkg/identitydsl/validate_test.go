@@ -0,0 +1,311 @@
+package identitydsl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	validate := func(t *testing.T, name, input string, want ...string) {
+		t.Run(name, func(t *testing.T) {
+			err := Check(input)
+
+			var got []string
+
+			if errs, ok := err.(Errors); ok {
+				for i := range errs {
+					got = append(got, errs[i].Msg)
+				}
+			} else if err != nil {
+				t.Fatalf("got error %v, want Errors", err)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+
+	validate(
+		t,
+		"valid",
+		`Account 123456789012, 098765432109
+	Team Data
+	Production
+
+Account 111111111111
+	Snowflake
+
+Group DataTeam
+Role ReadOnly
+
+Accounts Team Data
+	Assign
+		Account Production
+		Role ReadOnly
+		Group DataTeam`,
+	)
+
+	validate(
+		t,
+		"syntax errors only",
+		`Account 1
+Assign
+	Role Undefined`,
+		"Bad length account ID on line 1 position 1",
+	)
+
+	validate(
+		t,
+		"duplicates",
+		`Account 123456789012
+Account 098765432109, 123456789012
+User Bob
+Group Bob
+Group Bob
+Role ReadOnly, ReadOnly`,
+		"Duplicate Account 123456789012 on line 2, already declared on line 1",
+		"Duplicate Group Bob on line 5, already declared on line 4",
+		"Duplicate Role ReadOnly on line 6, already declared on line 6",
+	)
+
+	validate(
+		t,
+		"labels matching ids",
+		`Account 123456789012
+	098765432109
+Account 098765432109
+User Alice
+	Bob
+User Bob
+	Developers
+Group Developers`,
+		"Label '098765432109' matches the ID of a declared Account on line 2",
+		"Label 'Bob' matches the ID of a declared User on line 5",
+	)
+
+	validate(
+		t,
+		"undefined references",
+		`Account 123456789012
+	Production
+User Bob
+Role ReadOnly
+
+Accounts Staging
+	Assign
+		Account Production, 098765432109
+		User Bob, Alice
+		Role ReadOnly, Admin
+		Group Team Data`,
+		"Undefined Account 'Staging' on line 6",
+		"Undefined Account '098765432109' on line 8",
+		"Undefined User 'Alice' on line 9",
+		"Undefined Role 'Admin' on line 10",
+	)
+
+	validate(
+		t,
+		"roles by tag",
+		`Role ReadOnly
+Account 123456789012
+Group Developers
+Assign
+	Account 123456789012
+	Group Developers
+	Role Type ReadOnly`,
+		"Roles cannot be selected by tag on line 7",
+	)
+
+	validate(
+		t,
+		"incomplete assign",
+		`Account 123456789012
+Group Developers
+Role ReadOnly
+
+Assign
+	Role ReadOnly
+
+Accounts 123456789012
+	Groups Developers
+		Assign
+			Role ReadOnly
+	Assign
+		Group Developers`,
+		"Assign on line 5 does not select any accounts",
+		"Assign on line 5 does not select any users or groups",
+		"Assign on line 12 does not select any roles",
+	)
+
+	validate(
+		t,
+		"selectors matching nothing",
+		`Account 123456789012
+	Environment Dev
+Account 098765432109
+	Environment Production
+	Snowflake
+Group Developers
+Role ReadOnly
+
+Accounts Environment Dev
+	Assign
+		Account Snowflake
+		Role ReadOnly
+		Group Team Data`,
+		"Account selector 'Snowflake' matches nothing on line 11",
+		"Group selector 'Team Data' matches nothing on line 13",
+	)
+
+	validate(
+		t,
+		"unresolvable roles",
+		"Role ThisNameIsFarTooLongForAPermissionSet\n"+
+			"Role Ok\n\t"+strings.Repeat("p", 129),
+		"Role name 'ThisNameIsFarTooLongForAPermissionSet' is longer than 32 characters on line 1",
+		"Policy name '"+strings.Repeat("p", 129)+"' is longer than 128 characters on line 3",
+	)
+	validate(
+		t,
+		"organizational units",
+		`OU Workloads
+	Parent Root
+OU Production
+	Parent Workloads
+	Parent Sandbox
+OU Loop
+	Parent Cycle
+OU Cycle
+	Parent Loop
+OU Production
+Account 123456789012
+	OU Production
+Account 098765432109
+	OU Missing
+Group DBA
+Role ReadOnly
+
+Assign
+	Account OU Unknown, OU Workloads
+	Role ReadOnly
+	Group DBA`,
+		"Undefined OU 'Root' on line 2",
+		"Parent given more than once on line 5",
+		"OU Loop is beneath itself on line 6",
+		"OU Cycle is beneath itself on line 8",
+		"Duplicate OU Production on line 10, already declared on line 3",
+		"Undefined OU 'Missing' on line 14",
+		"Undefined OU 'Unknown' on line 19",
+	)
+	validate(
+		t,
+		"members",
+		`User Alice
+	Team Data
+Group DBA
+	Members Alice, Bob, Team Platform, Data`,
+		"Undefined User 'Bob' on line 4",
+		"Members selector 'Team Platform' matches nothing on line 4",
+		"Undefined User 'Data' on line 4",
+	)
+	validate(
+		t,
+		"role properties",
+		`Role ReadOnly
+	SessionDuration PT12H
+	RelayState https://console.aws.amazon.com/
+	Description "Read only"
+Role Short
+	SessionDuration 30m
+Role Long
+	SessionDuration PT12H1M
+Role Bad
+	SessionDuration eight
+	RelayState console.aws.amazon.com
+	Description "`+strings.Repeat("x", 701)+`"
+	Description Again`,
+		"SessionDuration '30m' is not between 1 and 12 hours on line 6",
+		"SessionDuration 'PT12H1M' is not between 1 and 12 hours on line 8",
+		"Invalid SessionDuration 'eight' on line 10",
+		"RelayState 'console.aws.amazon.com' is not a URL on line 11",
+		"Description is longer than 700 characters on line 12",
+		"Description given more than once on line 13",
+	)
+	validate(
+		t,
+		"deny",
+		`Account 111111111111
+	Production
+Account 222222222222
+	Development
+User Alice
+User Bob
+	Team Security
+Group Admins
+	Members Alice
+Group BreakGlass
+Role AdministratorAccess
+Role ReadOnly
+
+Deny
+	Account Production
+	Role AdministratorAccess
+	Except Group BreakGlass
+	Except User Team Security
+
+Assign
+	Account Production, Development
+	Role AdministratorAccess
+	Group Admins, BreakGlass
+	User Bob
+
+Assign
+	Account 111111111111
+	Role AdministratorAccess, ReadOnly
+	User Alice
+
+Deny
+	User Alice
+	Role ReadOnly`,
+		"Assign on line 20 gives Group Admins Role AdministratorAccess in Account 111111111111, denied on line 14",
+		"Assign on line 26 gives User Alice Role AdministratorAccess in Account 111111111111, denied on line 14",
+		"Assign on line 26 gives User Alice Role ReadOnly in Account 111111111111, denied on line 31",
+	)
+	validate(
+		t,
+		"deny selectors",
+		`Role ReadOnly
+Deny
+	Role Admin
+	Except Group Nobody
+Deny
+	Except Role ReadOnly`,
+		"Undefined Role 'Admin' on line 3",
+		"Undefined Group 'Nobody' on line 4",
+		"Deny on line 5 does not select anything",
+	)
+	validate(
+		t,
+		"policy partitions",
+		`Role ReadOnly
+	Boundary arn:aws-us-gov:iam::aws:policy/PowerUserAccess
+	arn:aws-us-gov:iam::aws:policy/ReadOnlyAccess
+	Custom
+Role Admin
+	arn:aws:iam::aws:policy/AdministratorAccess
+	/team/`+strings.Repeat("x", 129),
+		"Policy ARN 'arn:aws:iam::aws:policy/AdministratorAccess' is in the aws partition on line 6, but the ARN on line 2 is in aws-us-gov",
+		"Policy name '"+strings.Repeat("x", 129)+"' is longer than 128 characters on line 7",
+	)
+	validate(
+		t,
+		"policy resolution",
+		`Role ReadOnly
+	Boundary /security/`+strings.Repeat("b", 129)+`
+	/`+strings.Repeat("p", 512)+`/Custom
+	arn:aws:iam::aws:policy/job-function/ViewOnlyAccess`,
+		"Policy name '"+strings.Repeat("b", 129)+"' is longer than 128 characters on line 2",
+		"Policy path '/"+strings.Repeat("p", 512)+"/' is longer than 512 characters on line 3",
+	)
+}
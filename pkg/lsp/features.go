@@ -0,0 +1,361 @@
+package lsp
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+// load parses a document along with the files it includes. Documents which
+// aren't files on disk are parsed alone. The file the document was read from
+// is returned, to tell its blocks apart from those it includes.
+func load(uri, text string) (*identitydsl.Document, string, error) {
+	u, err := url.Parse(uri)
+
+	if err != nil || u.Scheme != "file" {
+		doc, err := identitydsl.Parse(text)
+		return doc, "", err
+	}
+
+	file := filepath.Clean(filepath.FromSlash(u.Path))
+	doc, err := identitydsl.LoadSource(file, text)
+
+	return doc, file, err
+}
+
+// fileURI makes the URI of a file.
+func fileURI(file string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+}
+
+// diagnose finds the syntax errors in a document and the files it includes,
+// or when there are none the logical errors and then the assignments Deny
+// blocks forbid, as validate would. Only the
+// problems found in the document itself are returned.
+func diagnose(uri, text string) []diagnostic {
+	doc, file, err := load(uri, text)
+
+	if err == nil {
+		err = identitydsl.Validate(doc)
+	}
+
+	if err == nil {
+		err = identitydsl.Enforce(doc)
+	}
+
+	errs, _ := err.(identitydsl.Errors)
+
+	out := []diagnostic{}
+	lines := splitLines(text)
+
+	for _, e := range errs {
+		if e.Pos.File != file {
+			continue
+		}
+
+		start := toPosition(lines, e.Pos)
+		end := position{start.Line, utf16Len(lineAt(lines, start.Line))}
+
+		out = append(out, diagnostic{
+			Range:    textRange{start, end},
+			Severity: 1,
+			Source:   "identitydsl",
+			Message:  e.Msg,
+		})
+	}
+
+	return out
+}
+
+// selectorLine matches the start of a line selecting entities, either within
+// an Assign or Deny block, as a context or as the members of a group.
+var selectorLine = regexp.MustCompile(`^(?:\s+(?:Except )?(Account|User|Group|Role)|\s*(Account|User|Group)s|\s+(Members)) `)
+
+// complete offers the IDs, labels and tag keys of the entities which can be
+// selected at a position in a document, including those declared in the
+// files it includes.
+func complete(uri, text string, pos position) []completionItem {
+	line := lineAt(splitLines(text), pos.Line)
+	match := selectorLine.FindStringSubmatch(line[:byteOffset(line, pos.Character)])
+
+	items := []completionItem{}
+
+	if match == nil {
+		return items
+	}
+
+	// The document is most likely unfinished, but whatever could be parsed
+	// is enough to offer completions.
+	doc, _, _ := load(uri, text)
+	m := identitydsl.NewModel(doc)
+
+	seen := map[string]bool{}
+
+	add := func(kind int, detail, label string) {
+		if seen[label] {
+			return
+		}
+
+		seen[label] = true
+
+		insert := label
+
+		if strings.Contains(label, " ") {
+			insert = `"` + label + `"`
+		}
+
+		items = append(items, completionItem{label, kind, detail, insert})
+	}
+
+	attributes := func(kind string, a identitydsl.Attributes) {
+		for _, l := range a.Labels {
+			add(itemValue, kind+" label", string(l))
+		}
+
+		for _, t := range a.Tags {
+			add(itemProperty, kind+" tag", t.Key)
+		}
+	}
+
+	switch match[1] + match[2] + match[3] {
+	case "Account":
+		for _, a := range m.Accounts {
+			add(itemConstant, "Account", a.ID)
+		}
+
+		for _, a := range m.Accounts {
+			attributes("Account", a.Attributes)
+		}
+	case "User", "Members":
+		for _, u := range m.Users {
+			add(itemConstant, "User", u.Name)
+		}
+
+		for _, u := range m.Users {
+			attributes("User", u.Attributes)
+		}
+	case "Group":
+		for _, g := range m.Groups {
+			add(itemConstant, "Group", g.Name)
+		}
+
+		for _, g := range m.Groups {
+			attributes("Group", g.Attributes)
+		}
+	case "Role":
+		for _, r := range m.Roles {
+			add(itemConstant, "Role", r.Name)
+		}
+	}
+
+	return items
+}
+
+// definition finds where the entities picked by the selector at a position
+// in a document are declared, which may be in a file it includes.
+func definition(uri, text string, pos position) []location {
+	doc, file, _ := load(uri, text)
+
+	if doc == nil {
+		return nil
+	}
+
+	lines := splitLines(text)
+	at := fromPosition(lines, pos)
+	at.File = file
+
+	kind, selector, ok := selectorAt(doc.Blocks, at)
+
+	if !ok {
+		return []location{}
+	}
+
+	m := identitydsl.NewModel(doc)
+
+	out := []location{}
+
+	// The lines of each included file are only read and split once.
+	included := map[string][]string{}
+
+	declared := func(pos identitydsl.Position, id string) {
+		in, where := uri, lines
+
+		if pos.File != file {
+			if _, ok := included[pos.File]; !ok {
+				data, err := os.ReadFile(pos.File)
+
+				if err != nil {
+					return
+				}
+
+				included[pos.File] = splitLines(string(data))
+			}
+
+			in, where = fileURI(pos.File), included[pos.File]
+		}
+
+		start := toPosition(where, pos)
+		end := position{start.Line, start.Character + utf16Len(id)}
+
+		out = append(out, location{in, textRange{start, end}})
+	}
+
+	switch kind {
+	case identitydsl.KindAccount:
+		for _, a := range m.Accounts {
+			if selector.Matches(a.ID, a.Attributes) {
+				declared(a.Pos, a.ID)
+			}
+		}
+	case identitydsl.KindUser:
+		for _, u := range m.Users {
+			if selector.Matches(u.Name, u.Attributes) {
+				declared(u.Pos, u.Name)
+			}
+		}
+	case identitydsl.KindGroup:
+		for _, g := range m.Groups {
+			if selector.Matches(g.Name, g.Attributes) {
+				declared(g.Pos, g.Name)
+			}
+		}
+	case identitydsl.KindRole:
+		for _, r := range m.Roles {
+			if !selector.IsTag() && selector.Value.Text == r.Name {
+				declared(r.Pos, r.Name)
+			}
+		}
+	}
+
+	return out
+}
+
+// selectorAt finds the selector written at a position, in a context, an
+// Assign or Deny block or a Members line of the same file.
+func selectorAt(blocks []identitydsl.Block, pos identitydsl.Position) (identitydsl.Kind, identitydsl.Selector, bool) {
+	find := func(selectors []identitydsl.Selector) (identitydsl.Selector, bool) {
+		for _, s := range selectors {
+			start, end := s.Start(), s.Value.Start
+
+			// Allow for a closing quote, and the cursor being just after.
+			last := end.Column + utf8.RuneCountInString(s.Value.Text) + 1
+
+			if start.File == pos.File && start.Line == pos.Line && start.Column <= pos.Column && pos.Column <= last {
+				return s, true
+			}
+		}
+
+		return identitydsl.Selector{}, false
+	}
+
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *identitydsl.EntityBlock:
+			for _, sel := range b.Members {
+				if s, ok := find(sel.Selectors); ok {
+					return sel.Kind, s, true
+				}
+			}
+		case *identitydsl.ContextBlock:
+			if s, ok := find(b.Selectors); ok {
+				return b.Kind, s, true
+			}
+
+			if kind, s, ok := selectorAt(b.Blocks, pos); ok {
+				return kind, s, true
+			}
+		case *identitydsl.AssignBlock:
+			for _, sel := range b.Selections {
+				if s, ok := find(sel.Selectors); ok {
+					return sel.Kind, s, true
+				}
+			}
+		case *identitydsl.DenyBlock:
+			for _, sel := range append(b.Selections[:len(b.Selections):len(b.Selections)], b.Exceptions...) {
+				if s, ok := find(sel.Selectors); ok {
+					return sel.Kind, s, true
+				}
+			}
+		}
+	}
+
+	return 0, identitydsl.Selector{}, false
+}
+
+// lineEndings turns each line ending the lexer recognises, a line feed, a
+// carriage return or the two together, into a line feed.
+var lineEndings = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// splitLines splits the text into lines, without their line endings.
+func splitLines(text string) []string {
+	return strings.Split(lineEndings.Replace(text), "\n")
+}
+
+// lineAt returns one of the lines, counting from zero, or an empty line past
+// the end.
+func lineAt(lines []string, n int) string {
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+
+	return lines[n]
+}
+
+// toPosition converts a position in a document, counting lines and runes
+// from one, to a protocol position counting lines and UTF-16 code units from
+// zero.
+func toPosition(lines []string, p identitydsl.Position) position {
+	line := lineAt(lines, p.Line-1)
+	runes := []rune(line)
+	column := min(max(p.Column-1, 0), len(runes))
+
+	return position{p.Line - 1, utf16Len(string(runes[:column]))}
+}
+
+// fromPosition converts a protocol position to a line and column in a
+// document. The offset is not worked out.
+func fromPosition(lines []string, p position) identitydsl.Position {
+	line := lineAt(lines, p.Line)
+
+	return identitydsl.Position{
+		Line:   p.Line + 1,
+		Column: utf8.RuneCountInString(line[:byteOffset(line, p.Character)]) + 1,
+	}
+}
+
+// byteOffset finds the byte offset in a line of a number of UTF-16 code
+// units.
+func byteOffset(line string, units int) int {
+	for i, r := range line {
+		if units <= 0 {
+			return i
+		}
+
+		units -= utf16RuneLen(r)
+	}
+
+	return len(line)
+}
+
+func utf16Len(s string) int {
+	n := 0
+
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+
+	return n
+}
+
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
@@ -0,0 +1,153 @@
+// Package lsp is a Language Server Protocol server for the identity DSL,
+// giving editors diagnostics, completion and go to definition.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Server holds the documents open in the editor. Messages are handled one at
+// a time, in the order received.
+type Server struct {
+	w    io.Writer
+	docs map[string]string
+}
+
+// Serve reads messages from r and writes replies to w until the editor
+// exits or r is closed.
+func Serve(r io.Reader, w io.Writer) error {
+	s := &Server{
+		w:    w,
+		docs: map[string]string{},
+	}
+
+	br := bufio.NewReader(r)
+
+	for {
+		m, err := readMessage(br)
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if errors.Is(err, errParse) {
+			if err := s.fail(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if m.Method == "exit" {
+			return nil
+		}
+
+		if err := s.handle(m); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a message, replying to requests. Notifications which
+// aren't supported are ignored, as the protocol asks.
+func (s *Server) handle(m *message) error {
+	switch m.Method {
+	case "initialize":
+		return s.reply(m, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": 1,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{" ", ","},
+				},
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{
+				"name": "identitydsl",
+			},
+		})
+	case "shutdown":
+		return s.reply(m, nil)
+	case "textDocument/didOpen":
+		var p didOpenParams
+
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil
+		}
+
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+
+		return s.publish(p.TextDocument.URI)
+	case "textDocument/didChange":
+		var p didChangeParams
+
+		if err := json.Unmarshal(m.Params, &p); err != nil || len(p.ContentChanges) == 0 {
+			return nil
+		}
+
+		s.docs[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+
+		return s.publish(p.TextDocument.URI)
+	case "textDocument/didClose":
+		var p didCloseParams
+
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil
+		}
+
+		delete(s.docs, p.TextDocument.URI)
+
+		return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         p.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+	case "textDocument/completion":
+		var p textDocumentPositionParams
+
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return s.fail(m.ID, codeInvalidParams, err.Error())
+		}
+
+		return s.reply(m, complete(p.TextDocument.URI, s.docs[p.TextDocument.URI], p.Position))
+	case "textDocument/definition":
+		var p textDocumentPositionParams
+
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return s.fail(m.ID, codeInvalidParams, err.Error())
+		}
+
+		return s.reply(m, definition(p.TextDocument.URI, s.docs[p.TextDocument.URI], p.Position))
+	}
+
+	if m.ID != nil {
+		return s.fail(m.ID, codeMethodNotFound, "method not found: "+m.Method)
+	}
+
+	return nil
+}
+
+// publish sends the diagnostics for a document.
+func (s *Server) publish(uri string) error {
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnose(uri, s.docs[uri]),
+	})
+}
+
+func (s *Server) reply(m *message, result interface{}) error {
+	return writeMessage(s.w, response{"2.0", m.ID, result})
+}
+
+func (s *Server) fail(id *json.RawMessage, code int, msg string) error {
+	return writeMessage(s.w, errorResponse{"2.0", id, responseError{code, msg}})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.w, notification{"2.0", method, params})
+}
@@ -0,0 +1,263 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// session sends the messages to a server and returns the messages it writes
+// back, decoded.
+func session(t *testing.T, messages ...string) []map[string]interface{} {
+	t.Helper()
+
+	var in, out bytes.Buffer
+
+	for _, m := range messages {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+
+	if err := Serve(&in, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var replies []map[string]interface{}
+
+	r := bufio.NewReader(&out)
+
+	for r.Buffered() > 0 || out.Len() > 0 {
+		data, err := readFrame(r)
+
+		if err != nil {
+			t.Fatalf("bad reply: %v", err)
+		}
+
+		var reply map[string]interface{}
+
+		if err := json.Unmarshal(data, &reply); err != nil {
+			t.Fatalf("bad reply: %v", err)
+		}
+
+		replies = append(replies, reply)
+	}
+
+	return replies
+}
+
+// request makes a JSON-RPC request or notification.
+func request(id int, method string, params interface{}) string {
+	m := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+
+	if id > 0 {
+		m["id"] = id
+	}
+
+	data, _ := json.Marshal(m)
+
+	return string(data)
+}
+
+// didOpen opens a document in the server.
+func didOpen(text string) string {
+	return request(0, "textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":  "file:///ic.txt",
+			"text": text,
+		},
+	})
+}
+
+// at makes the parameters of a request at a position in the document.
+func at(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///ic.txt"},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+const document = `Account 111111111111
+	Production
+	"Cost Centre" Data
+Account 222222222222
+	Production
+Group DBA
+User Bob
+Role ReadOnly
+
+Assign
+	Account Production
+	Role ReadOnly
+	Group DBA
+`
+
+func TestServer(t *testing.T) {
+	t.Run("lifecycle", func(t *testing.T) {
+		replies := session(
+			t,
+			request(1, "initialize", map[string]interface{}{}),
+			request(0, "initialized", map[string]interface{}{}),
+			request(2, "unknown", nil),
+			request(3, "shutdown", nil),
+			request(0, "exit", nil),
+			request(4, "never handled", nil),
+		)
+
+		if len(replies) != 3 {
+			t.Fatalf("got %d replies, want 3: %v", len(replies), replies)
+		}
+
+		capabilities := replies[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+
+		if capabilities["definitionProvider"] != true {
+			t.Errorf("got capabilities %v, want definitionProvider", capabilities)
+		}
+
+		if code := replies[1]["error"].(map[string]interface{})["code"]; code != float64(codeMethodNotFound) {
+			t.Errorf("got error code %v, want %d", code, codeMethodNotFound)
+		}
+
+		if result, ok := replies[2]["result"]; !ok || result != nil {
+			t.Errorf("got shutdown reply %v, want a null result", replies[2])
+		}
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		replies := session(t, didOpen(document+"\tUser Alice\n"))
+
+		params := replies[0]["params"].(map[string]interface{})
+		diagnostics := params["diagnostics"].([]interface{})
+
+		if len(diagnostics) != 1 {
+			t.Fatalf("got %d diagnostics, want 1: %v", len(diagnostics), diagnostics)
+		}
+
+		d := diagnostics[0].(map[string]interface{})
+
+		if d["message"] != "Undefined User 'Alice' on line 14" {
+			t.Errorf("got message %q", d["message"])
+		}
+
+		want := map[string]interface{}{
+			"start": map[string]interface{}{"line": float64(13), "character": float64(6)},
+			"end":   map[string]interface{}{"line": float64(13), "character": float64(11)},
+		}
+
+		if !reflect.DeepEqual(d["range"], want) {
+			t.Errorf("got range %v, want %v", d["range"], want)
+		}
+	})
+
+	t.Run("line endings", func(t *testing.T) {
+		replies := session(t, didOpen(strings.ReplaceAll(document+"\tUser Alice\n", "\n", "\r")))
+
+		params := replies[0]["params"].(map[string]interface{})
+		d := params["diagnostics"].([]interface{})[0].(map[string]interface{})
+
+		want := map[string]interface{}{
+			"start": map[string]interface{}{"line": float64(13), "character": float64(6)},
+			"end":   map[string]interface{}{"line": float64(13), "character": float64(11)},
+		}
+
+		if !reflect.DeepEqual(d["range"], want) {
+			t.Errorf("got range %v, want %v", d["range"], want)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		text := document + "Group Admins\n\tMembers \nDeny\n\tExcept Group \n"
+
+		complete := func(name string, line, character int, want ...string) {
+			t.Run(name, func(t *testing.T) {
+				replies := session(t, didOpen(text), request(1, "textDocument/completion", at(line, character)))
+
+				var got []string
+
+				for _, item := range replies[1]["result"].([]interface{}) {
+					got = append(got, item.(map[string]interface{})["insertText"].(string))
+				}
+
+				if strings.Join(got, " ") != strings.Join(want, " ") {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			})
+		}
+
+		complete("accounts", 10, 9, "111111111111", "222222222222", "Production", `"Cost Centre"`)
+		complete("roles", 11, 6, "ReadOnly")
+		complete("groups", 12, 7, "DBA")
+		complete("members", 14, 9, "Bob")
+		complete("except", 16, 14, "DBA")
+		complete("elsewhere", 1, 3)
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		replies := session(t, didOpen(document), request(1, "textDocument/definition", at(10, 12)))
+
+		var got []string
+
+		for _, l := range replies[1]["result"].([]interface{}) {
+			start := l.(map[string]interface{})["range"].(map[string]interface{})["start"].(map[string]interface{})
+			got = append(got, fmt.Sprint(start["line"], ":", start["character"]))
+		}
+
+		if want := []string{"0:8", "3:8"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("include", func(t *testing.T) {
+		dir := t.TempDir()
+		people := filepath.Join(dir, "people.idsl")
+
+		if err := os.WriteFile(people, []byte("Group Platform\nGroup DBA\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		uri := fileURI(filepath.Join(dir, "main.idsl"))
+		text := "Include \"people.idsl\"\n" + document
+
+		replies := session(
+			t,
+			request(0, "textDocument/didOpen", map[string]interface{}{
+				"textDocument": map[string]interface{}{"uri": uri, "text": text},
+			}),
+			request(1, "textDocument/definition", map[string]interface{}{
+				"textDocument": map[string]interface{}{"uri": uri},
+				"position":     map[string]interface{}{"line": 13, "character": 8},
+			}),
+		)
+
+		// Group DBA is declared in both files, which is reported against the
+		// document being edited.
+		diagnostics := replies[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+
+		if len(diagnostics) != 1 {
+			t.Fatalf("got %d diagnostics, want 1: %v", len(diagnostics), diagnostics)
+		}
+
+		if got, want := diagnostics[0].(map[string]interface{})["message"], "Duplicate Group DBA on line 7, already declared in "+people+" on line 2"; got != want {
+			t.Errorf("got message %q, want %q", got, want)
+		}
+
+		var got []string
+
+		for _, l := range replies[1]["result"].([]interface{}) {
+			l := l.(map[string]interface{})
+			start := l["range"].(map[string]interface{})["start"].(map[string]interface{})
+			got = append(got, fmt.Sprint(l["uri"] == uri, ":", start["line"]))
+		}
+
+		if want := []string{"false:1", "true:6"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package terraform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// Addresses records the names given to synthesized resources, so they stay
+// the same from one run to the next, and the moves made when an entity is
+// renamed. It is meant to be kept alongside the generated configuration.
+type Addresses struct {
+	Resources []Resource `json:"resources"`
+
+	// Fingerprints summarise the attributes of each user, group and role, by
+	// key, so a renamed entity can be recognised on the next run.
+	Fingerprints map[string]string `json:"fingerprints,omitempty"`
+
+	// Moves are kept from run to run, so a configuration can still be applied
+	// to state which missed an earlier rename.
+	Moves []Move `json:"moves,omitempty"`
+}
+
+// Resource is the name given to the resource of a type made for the entities
+// in its key, such as "group:DBA".
+type Resource struct {
+	Type string   `json:"type"`
+	Key  []string `json:"key"`
+	Name string   `json:"name"`
+}
+
+// Move is a rename of a resource, between two resource addresses.
+type Move struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// namer gives out resource names during a run, keeping those from previous
+// runs and making sure no two resources of a type share a name.
+type namer struct {
+	previous     map[string]string
+	names        map[string]string
+	used         map[string]bool
+	renames      map[string]string
+	resources    []Resource
+	moves        []Move
+	fingerprints map[string]string
+}
+
+func newNamer(a *Addresses) *namer {
+	n := &namer{
+		previous:     map[string]string{},
+		names:        map[string]string{},
+		used:         map[string]bool{},
+		fingerprints: map[string]string{},
+		moves:        a.Moves,
+	}
+
+	for _, r := range a.Resources {
+		n.previous[resourceID(r.Type, r.Key)] = r.Name
+		n.used[r.Type+"."+r.Name] = true
+	}
+
+	return n
+}
+
+// resourceID joins a type and key into one string for use in maps.
+func resourceID(typ string, key []string) string {
+	return typ + "\x00" + strings.Join(key, "\x00")
+}
+
+// entity records the fingerprint of an entity for this run.
+func (n *namer) entity(key string, values ...string) {
+	n.fingerprints[key] = fingerprint(kindOf(key), values)
+}
+
+// detectRenames pairs each entity which has gone since the previous run with
+// a new entity of the same kind and fingerprint, when each pairing is the
+// only one possible.
+func (n *namer) detectRenames(previous map[string]string) {
+	gone := map[string][]string{}
+	added := map[string][]string{}
+
+	for key, fp := range previous {
+		if _, ok := n.fingerprints[key]; !ok && fp != "" {
+			gone[fp] = append(gone[fp], key)
+		}
+	}
+
+	for key, fp := range n.fingerprints {
+		if _, ok := previous[key]; !ok && fp != "" {
+			added[fp] = append(added[fp], key)
+		}
+	}
+
+	n.renames = map[string]string{}
+
+	for fp, keys := range added {
+		if len(keys) == 1 && len(gone[fp]) == 1 {
+			n.renames[keys[0]] = gone[fp][0]
+		}
+	}
+}
+
+// kindOf returns the kind of entity in a key, such as "group" in "group:DBA".
+func kindOf(key string) string {
+	return key[:strings.Index(key, ":")]
+}
+
+// name returns the name of the resource of a type with a key, made from the
+// given parts unless it has been named before. A resource for a renamed
+// entity takes a new name, with a move from the old one.
+func (n *namer) name(typ string, key []string, parts ...string) string {
+	id := resourceID(typ, key)
+
+	if s, ok := n.names[id]; ok {
+		return s
+	}
+
+	s, ok := n.previous[id]
+
+	if !ok {
+		s = n.rename(typ, key, parts)
+	}
+
+	n.names[id] = s
+	n.used[typ+"."+s] = true
+	n.resources = append(n.resources, Resource{typ, key, s})
+
+	return s
+}
+
+func (n *namer) rename(typ string, key, parts []string) string {
+	before := make([]string, len(key))
+	renamed := false
+
+	for i, k := range key {
+		before[i] = k
+
+		if old, ok := n.renames[k]; ok {
+			before[i] = old
+			renamed = true
+		}
+	}
+
+	old, ok := n.previous[resourceID(typ, before)]
+
+	if !renamed || !ok {
+		return n.unique(typ, key, parts)
+	}
+
+	// Free the old name in case the new one comes out the same.
+	delete(n.used, typ+"."+old)
+
+	s := n.unique(typ, key, parts)
+
+	n.used[typ+"."+old] = true
+
+	if s != old {
+		n.moves = append(n.moves, Move{typ + "." + old, typ + "." + s})
+	}
+
+	return s
+}
+
+// movedFrom adds a move to an address from the resource of a type with a key
+// from the previous run, when it is no longer named. The index picks out an
+// instance of the old resource.
+func (n *namer) movedFrom(typ string, key []string, index, to string) {
+	id := resourceID(typ, key)
+
+	if old, ok := n.previous[id]; ok && n.names[id] == "" {
+		n.moves = append(n.moves, Move{typ + "." + old + index, to})
+	}
+}
+
+// unique makes a name from the parts, adding a hash of the key when another
+// resource of the type already has that name.
+func (n *namer) unique(typ string, key, parts []string) string {
+	s := name(parts...)
+
+	if n.used[typ+"."+s] {
+		sum := sha256.Sum256([]byte(strings.Join(key, "\x00")))
+		s += "_" + hex.EncodeToString(sum[:4])
+	}
+
+	return s
+}
+
+// save updates the addresses with the names given out in this run. Moves
+// away from a resource which is in use again, or from one of its instances,
+// are dropped, as Terraform would reject them.
+func (n *namer) save(a *Addresses) {
+	current := map[string]bool{}
+
+	for _, r := range n.resources {
+		current[r.Type+"."+r.Name] = true
+	}
+
+	var moves []Move
+
+	for _, m := range n.moves {
+		from := m.From
+
+		if i := strings.Index(from, "["); i >= 0 {
+			from = from[:i]
+		}
+
+		if !current[from] {
+			moves = append(moves, m)
+		}
+	}
+
+	a.Resources = n.resources
+	a.Fingerprints = n.fingerprints
+	a.Moves = moves
+}
+
+// fingerprint summarises the attributes of an entity. Entities without any
+// attributes can't be told apart, so have no fingerprint.
+func fingerprint(kind string, values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	values = append([]string{}, values...)
+
+	sort.Strings(values)
+
+	sum := sha256.Sum256([]byte(kind + "\x00" + strings.Join(values, "\x00")))
+
+	return hex.EncodeToString(sum[:8])
+}
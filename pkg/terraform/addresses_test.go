@@ -0,0 +1,183 @@
+package terraform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+// synthesizeWith synthesizes the input using and updating the addresses,
+// returning the resource addresses and moves in the configuration.
+func synthesizeWith(t *testing.T, addresses *Addresses, opts Options, input string) (resources, moves []string) {
+	t.Helper()
+
+	doc, err := identitydsl.Parse(input)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assignments, err := identitydsl.Expand(doc)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, b := range Synthesize(identitydsl.NewModel(doc), assignments, addresses, opts).Blocks {
+		switch b.Type {
+		case "resource":
+			resources = append(resources, strings.Join(b.Labels, "."))
+		case "moved":
+			moves = append(moves, string(b.Body.Attributes[0].Value.(Traversal))+" -> "+string(b.Body.Attributes[1].Value.(Traversal)))
+		}
+	}
+
+	return resources, moves
+}
+
+func TestAddresses(t *testing.T) {
+	t.Run("collisions", func(t *testing.T) {
+		resources, _ := synthesizeWith(t, &Addresses{}, Options{}, "User Bob.Smith\nUser Bob_Smith")
+
+		want := []string{
+			"aws_identitystore_user.Bob_Smith",
+			"aws_identitystore_user.Bob_Smith_4bdd6489",
+		}
+
+		if !reflect.DeepEqual(resources, want) {
+			t.Errorf("got %v, want %v", resources, want)
+		}
+	})
+
+	t.Run("stable", func(t *testing.T) {
+		addresses := &Addresses{}
+
+		synthesizeWith(t, addresses, Options{}, "User Bob_Smith")
+
+		resources, _ := synthesizeWith(t, addresses, Options{}, "User Bob.Smith\nUser Bob_Smith")
+
+		want := []string{
+			"aws_identitystore_user.Bob_Smith_131ee923",
+			"aws_identitystore_user.Bob_Smith",
+		}
+
+		if !reflect.DeepEqual(resources, want) {
+			t.Errorf("got %v, want %v", resources, want)
+		}
+	})
+
+	const before = `Account 123456789012
+Group DBA
+	Description "Database administrators"
+Group Other
+Role ReadOnly
+Assign
+	Account 123456789012
+	Role ReadOnly
+	Group DBA, Other`
+
+	const after = `Account 123456789012
+Group DatabaseAdmins
+	Description "Database administrators"
+Group Another
+Role ReadOnly
+Assign
+	Account 123456789012
+	Role ReadOnly
+	Group DatabaseAdmins, Another`
+
+	t.Run("renames", func(t *testing.T) {
+		addresses := &Addresses{}
+
+		synthesizeWith(t, addresses, Options{}, before)
+
+		_, moves := synthesizeWith(t, addresses, Options{}, after)
+
+		want := []string{
+			"aws_identitystore_group.DBA -> aws_identitystore_group.DatabaseAdmins",
+			"aws_ssoadmin_account_assignment._123456789012_group_DBA_ReadOnly -> aws_ssoadmin_account_assignment._123456789012_group_DatabaseAdmins_ReadOnly",
+		}
+
+		if !reflect.DeepEqual(moves, want) {
+			t.Errorf("got %v, want %v", moves, want)
+		}
+
+		// The moves are kept until an address is used again.
+		_, moves = synthesizeWith(t, addresses, Options{}, after)
+
+		if !reflect.DeepEqual(moves, want) {
+			t.Errorf("got %v, want %v", moves, want)
+		}
+
+		_, moves = synthesizeWith(t, addresses, Options{}, before)
+
+		want = []string{
+			"aws_identitystore_group.DatabaseAdmins -> aws_identitystore_group.DBA",
+			"aws_ssoadmin_account_assignment._123456789012_group_DatabaseAdmins_ReadOnly -> aws_ssoadmin_account_assignment._123456789012_group_DBA_ReadOnly",
+		}
+
+		if !reflect.DeepEqual(moves, want) {
+			t.Errorf("got %v, want %v", moves, want)
+		}
+	})
+
+	t.Run("roles", func(t *testing.T) {
+		addresses := &Addresses{}
+
+		synthesizeWith(t, addresses, Options{}, "Role Admin\n\tAdministratorAccess\nRole ReadOnly\n\tDescription \"Read only\"\nRole Billing\nRole Audit")
+
+		_, moves := synthesizeWith(t, addresses, Options{}, "Role Administrator\n\tAdministratorAccess\nRole ReadOnlyAccess\n\tDescription \"Read only\"\nRole Billing\nRole Auditor")
+
+		want := []string{
+			"aws_ssoadmin_permission_set.Admin -> aws_ssoadmin_permission_set.Administrator",
+			"aws_ssoadmin_customer_managed_policy_attachment.Admin_AdministratorAccess -> aws_ssoadmin_customer_managed_policy_attachment.Administrator_AdministratorAccess",
+			"aws_ssoadmin_permission_set.ReadOnly -> aws_ssoadmin_permission_set.ReadOnlyAccess",
+		}
+
+		if !reflect.DeepEqual(moves, want) {
+			t.Errorf("got %v, want %v", moves, want)
+		}
+	})
+
+	t.Run("unrelated", func(t *testing.T) {
+		addresses := &Addresses{}
+
+		synthesizeWith(t, addresses, Options{}, "User alice\n\tEmail alice@example.com\nUser bob")
+
+		_, moves := synthesizeWith(t, addresses, Options{}, "User mallory\n\tEmail mallory@example.com\nUser bob")
+
+		if len(moves) != 0 {
+			t.Errorf("got moves %v, want none", moves)
+		}
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		addresses := &Addresses{}
+
+		synthesizeWith(t, addresses, Options{}, before)
+
+		_, moves := synthesizeWith(t, addresses, Options{Compact: true}, before)
+
+		want := []string{
+			`aws_ssoadmin_account_assignment._123456789012_group_DBA_ReadOnly -> aws_ssoadmin_account_assignment.group_DBA_ReadOnly["123456789012"]`,
+			`aws_ssoadmin_account_assignment._123456789012_group_Other_ReadOnly -> aws_ssoadmin_account_assignment.group_Other_ReadOnly["123456789012"]`,
+		}
+
+		if !reflect.DeepEqual(moves, want) {
+			t.Errorf("got %v, want %v", moves, want)
+		}
+
+		_, moves = synthesizeWith(t, addresses, Options{}, before)
+
+		want = []string{
+			`aws_ssoadmin_account_assignment.group_DBA_ReadOnly["123456789012"] -> aws_ssoadmin_account_assignment._123456789012_group_DBA_ReadOnly`,
+			`aws_ssoadmin_account_assignment.group_Other_ReadOnly["123456789012"] -> aws_ssoadmin_account_assignment._123456789012_group_Other_ReadOnly`,
+		}
+
+		if !reflect.DeepEqual(moves, want) {
+			t.Errorf("got %v, want %v", moves, want)
+		}
+	})
+}
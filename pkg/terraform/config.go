@@ -0,0 +1,198 @@
+// Package terraform synthesizes Terraform configuration for IAM Identity
+// Center from an identity model.
+package terraform
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Config is a Terraform configuration made up of top level blocks.
+type Config struct {
+	Blocks []*Block
+}
+
+// Block is a Terraform block such as a resource, with its labels and body.
+type Block struct {
+	Type   string
+	Labels []string
+	Body   Body
+}
+
+// Body holds the attributes and nested blocks of a block, in order.
+type Body struct {
+	Attributes []Attribute
+	Blocks     []*Block
+}
+
+// Attribute is a named value within a body.
+type Attribute struct {
+	Name  string
+	Value Expr
+}
+
+// Expr is the value of an attribute.
+type Expr interface {
+	expr()
+}
+
+// String is a literal string.
+type String string
+
+// Bool is a literal boolean.
+type Bool bool
+
+// Ref is an expression referring to other objects, such as a resource
+// attribute or the result of a function.
+type Ref string
+
+// Traversal is a bare reference to an object, such as a resource address in
+// a moved block, which the JSON syntax expects without interpolation.
+type Traversal string
+
+// List is a list of values.
+type List []Expr
+
+// Object is a collection of named values, kept in order.
+type Object []Attribute
+
+func (String) expr()    {}
+func (Bool) expr()      {}
+func (Ref) expr()       {}
+func (Traversal) expr() {}
+func (List) expr()      {}
+func (Object) expr()    {}
+
+// Add appends a block to the configuration, returning it for the body to be
+// filled in.
+func (c *Config) Add(typ string, labels ...string) *Block {
+	b := &Block{
+		Type:   typ,
+		Labels: labels,
+	}
+
+	c.Blocks = append(c.Blocks, b)
+
+	return b
+}
+
+// Set appends an attribute to the body.
+func (b *Body) Set(name string, value Expr) {
+	b.Attributes = append(b.Attributes, Attribute{name, value})
+}
+
+// Add appends a nested block to the body, returning it for its own body to be
+// filled in.
+func (b *Body) Add(typ string, labels ...string) *Block {
+	n := &Block{
+		Type:   typ,
+		Labels: labels,
+	}
+
+	b.Blocks = append(b.Blocks, n)
+
+	return n
+}
+
+// JSON encodes the configuration using the Terraform JSON syntax.
+func (c *Config) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(jsonBlocks(c.Blocks), "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// jsonBlocks nests each block within objects keyed by its type then each of
+// its labels. Blocks without labels are kept in a list when repeated.
+func jsonBlocks(blocks []*Block) map[string]interface{} {
+	out := map[string]interface{}{}
+
+	for _, b := range blocks {
+		if len(b.Labels) == 0 {
+			switch existing := out[b.Type].(type) {
+			case nil:
+				out[b.Type] = jsonBody(b.Body)
+			case []interface{}:
+				out[b.Type] = append(existing, jsonBody(b.Body))
+			default:
+				out[b.Type] = []interface{}{existing, jsonBody(b.Body)}
+			}
+			continue
+		}
+
+		parent, ok := out[b.Type].(map[string]interface{})
+
+		if !ok {
+			parent = map[string]interface{}{}
+			out[b.Type] = parent
+		}
+
+		for _, label := range b.Labels[:len(b.Labels)-1] {
+			child, ok := parent[label].(map[string]interface{})
+
+			if !ok {
+				child = map[string]interface{}{}
+				parent[label] = child
+			}
+
+			parent = child
+		}
+
+		parent[b.Labels[len(b.Labels)-1]] = jsonBody(b.Body)
+	}
+
+	return out
+}
+
+func jsonBody(b Body) map[string]interface{} {
+	out := jsonBlocks(b.Blocks)
+
+	for _, a := range b.Attributes {
+		out[a.Name] = jsonExpr(a.Value)
+	}
+
+	return out
+}
+
+func jsonExpr(e Expr) interface{} {
+	switch e := e.(type) {
+	case String:
+		return escapeTemplate(string(e))
+	case Bool:
+		return bool(e)
+	case Ref:
+		return "${" + string(e) + "}"
+	case Traversal:
+		return string(e)
+	case List:
+		out := make([]interface{}, len(e))
+
+		for i := range e {
+			out[i] = jsonExpr(e[i])
+		}
+
+		return out
+	case Object:
+		out := map[string]interface{}{}
+
+		for _, a := range e {
+			out[a.Name] = jsonExpr(a.Value)
+		}
+
+		return out
+	}
+
+	return nil
+}
+
+// escapeTemplate stops Terraform treating parts of a literal string as
+// template sequences.
+func escapeTemplate(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+
+	return s
+}
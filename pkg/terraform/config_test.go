@@ -0,0 +1,90 @@
+package terraform
+
+import (
+	"testing"
+)
+
+func TestConfigJSON(t *testing.T) {
+	c := &Config{}
+
+	c.Add("data", "aws_ssoadmin_instances", "this")
+
+	locals := c.Add("locals")
+	locals.Body.Set("instance_arn", Ref("tolist(data.aws_ssoadmin_instances.this.arns)[0]"))
+	locals.Body.Set("accounts", Object{{"group_DBA", List{String("1"), String("2")}}})
+
+	r := c.Add("resource", "aws_identitystore_user", "bob")
+	r.Body.Set("user_name", String("bob ${not a template}"))
+
+	n := r.Body.Add("emails")
+	n.Body.Set("primary", Bool(true))
+
+	c.Add("resource", "aws_identitystore_user", "alice")
+
+	c.Add("moved").Body.Set("from", Traversal("a.b"))
+	c.Add("moved").Body.Set("from", Traversal("c.d"))
+
+	got, err := c.JSON()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{
+  "data": {
+    "aws_ssoadmin_instances": {
+      "this": {}
+    }
+  },
+  "locals": {
+    "accounts": {
+      "group_DBA": [
+        "1",
+        "2"
+      ]
+    },
+    "instance_arn": "${tolist(data.aws_ssoadmin_instances.this.arns)[0]}"
+  },
+  "moved": [
+    {
+      "from": "a.b"
+    },
+    {
+      "from": "c.d"
+    }
+  ],
+  "resource": {
+    "aws_identitystore_user": {
+      "alice": {},
+      "bob": {
+        "emails": {
+          "primary": true
+        },
+        "user_name": "bob $${not a template}"
+      }
+    }
+  }
+}
+`
+
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestName(t *testing.T) {
+	for _, test := range []struct {
+		parts []string
+		want  string
+	}{
+		{[]string{"ReadOnly"}, "ReadOnly"},
+		{[]string{"Bob.Smith"}, "Bob_Smith"},
+		{[]string{"bob@example.com"}, "bob_example_com"},
+		{[]string{"123456789012", "group", "DBA", "ReadOnly"}, "_123456789012_group_DBA_ReadOnly"},
+		{[]string{"-x"}, "_-x"},
+	} {
+		if got := name(test.parts...); got != test.want {
+			t.Errorf("name(%q) = %q, want %q", test.parts, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// HCL encodes the configuration using the Terraform native syntax, laid out
+// as terraform fmt would leave it. Blocks are written in the order they were
+// added, so the same configuration always encodes to the same bytes.
+func (c *Config) HCL() []byte {
+	var buf bytes.Buffer
+
+	for i, b := range c.Blocks {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+
+		hclBlock(&buf, b, 0)
+	}
+
+	return buf.Bytes()
+}
+
+func hclBlock(buf *bytes.Buffer, b *Block, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	buf.WriteString(indent + b.Type)
+
+	for _, label := range b.Labels {
+		buf.WriteString(" " + quoteHCL(label))
+	}
+
+	if len(b.Body.Attributes) == 0 && len(b.Body.Blocks) == 0 {
+		buf.WriteString(" {}\n")
+		return
+	}
+
+	buf.WriteString(" {\n")
+
+	hclBody(buf, b.Body, depth+1)
+
+	buf.WriteString(indent + "}\n")
+}
+
+// hclBody writes the attributes of a body followed by its nested blocks,
+// each block set apart by a blank line.
+func hclBody(buf *bytes.Buffer, b Body, depth int) {
+	hclAttributes(buf, b.Attributes, depth)
+
+	for i, n := range b.Blocks {
+		if i > 0 || len(b.Attributes) > 0 {
+			buf.WriteByte('\n')
+		}
+
+		hclBlock(buf, n, depth)
+	}
+}
+
+// hclAttributes writes attributes one per line, aligning the equals signs of
+// each run of single line values as terraform fmt does.
+func hclAttributes(buf *bytes.Buffer, attributes []Attribute, depth int) {
+	indent := strings.Repeat("  ", depth)
+	names := make([]string, len(attributes))
+	values := make([]string, len(attributes))
+
+	for i, a := range attributes {
+		names[i] = a.Name
+
+		if !isIdentifier(a.Name) {
+			names[i] = quoteHCL(a.Name)
+		}
+
+		values[i] = hclExpr(a.Value, depth)
+	}
+
+	for i := 0; i < len(attributes); {
+		if strings.Contains(values[i], "\n") {
+			fmt.Fprintf(buf, "%s%s = %s\n", indent, names[i], values[i])
+			i++
+			continue
+		}
+
+		end, width := i, 0
+
+		for ; end < len(attributes) && !strings.Contains(values[end], "\n"); end++ {
+			width = max(width, len(names[end]))
+		}
+
+		for ; i < end; i++ {
+			fmt.Fprintf(buf, "%s%-*s = %s\n", indent, width, names[i], values[i])
+		}
+	}
+}
+
+// hclExpr formats a value, placing any lines after the first one level
+// deeper than the depth of the attribute it belongs to.
+func hclExpr(e Expr, depth int) string {
+	switch e := e.(type) {
+	case String:
+		return quoteHCL(escapeTemplate(string(e)))
+	case Bool:
+		return fmt.Sprint(bool(e))
+	case Ref:
+		return string(e)
+	case Traversal:
+		return string(e)
+	case List:
+		if len(e) == 0 {
+			return "[]"
+		}
+
+		indent := strings.Repeat("  ", depth)
+
+		var b strings.Builder
+
+		b.WriteString("[\n")
+
+		for _, item := range e {
+			b.WriteString(indent + "  " + hclExpr(item, depth+1) + ",\n")
+		}
+
+		b.WriteString(indent + "]")
+
+		return b.String()
+	case Object:
+		if len(e) == 0 {
+			return "{}"
+		}
+
+		var buf bytes.Buffer
+
+		buf.WriteString("{\n")
+
+		hclAttributes(&buf, e, depth+1)
+
+		buf.WriteString(strings.Repeat("  ", depth) + "}")
+
+		return buf.String()
+	}
+
+	return "null"
+}
+
+// isIdentifier reports whether s can be written as a bare HCL identifier.
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-'):
+		default:
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// quoteHCL quotes a string using the escapes HCL understands, which are
+// fewer than Go's.
+func quoteHCL(s string) string {
+	var b strings.Builder
+
+	b.WriteByte('"')
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	b.WriteByte('"')
+
+	return b.String()
+}
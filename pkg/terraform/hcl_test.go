@@ -0,0 +1,92 @@
+package terraform
+
+import "testing"
+
+func TestConfigHCL(t *testing.T) {
+	c := &Config{}
+
+	c.Add("data", "aws_ssoadmin_instances", "this")
+
+	locals := c.Add("locals")
+	locals.Body.Set("instance_arn", Ref("tolist(data.aws_ssoadmin_instances.this.arns)[0]"))
+	locals.Body.Set("identity_store_id", Ref("tolist(data.aws_ssoadmin_instances.this.identity_store_ids)[0]"))
+
+	r := c.Add("resource", "aws_identitystore_user", "bob")
+	r.Body.Set("user_name", String("bob ${not a template}"))
+	r.Body.Set("display_name", String("Bob \"The Builder\" Smith\\"))
+
+	n := r.Body.Add("name")
+	n.Body.Set("given_name", String("Bob"))
+
+	n = r.Body.Add("emails")
+	n.Body.Set("value", String("bob@example.com"))
+	n.Body.Set("primary", Bool(true))
+
+	r = c.Add("resource", "aws_identitystore_group", "dba")
+	r.Body.Add("empty")
+
+	want := `data "aws_ssoadmin_instances" "this" {}
+
+locals {
+  instance_arn      = tolist(data.aws_ssoadmin_instances.this.arns)[0]
+  identity_store_id = tolist(data.aws_ssoadmin_instances.this.identity_store_ids)[0]
+}
+
+resource "aws_identitystore_user" "bob" {
+  user_name    = "bob $${not a template}"
+  display_name = "Bob \"The Builder\" Smith\\"
+
+  name {
+    given_name = "Bob"
+  }
+
+  emails {
+    value   = "bob@example.com"
+    primary = true
+  }
+}
+
+resource "aws_identitystore_group" "dba" {
+  empty {}
+}
+`
+
+	if got := string(c.HCL()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConfigHCLCollections(t *testing.T) {
+	c := &Config{}
+
+	locals := c.Add("locals")
+	locals.Body.Set("a", String("x"))
+	locals.Body.Set("bbb", String("y"))
+	locals.Body.Set("accounts", Object{
+		{"group_DBA", List{String("1"), String("2")}},
+		{"user_Bob", List{}},
+		{"not an identifier", Object{}},
+		{"c", Bool(false)},
+	})
+	locals.Body.Set("dd", String("z"))
+
+	want := `locals {
+  a   = "x"
+  bbb = "y"
+  accounts = {
+    group_DBA = [
+      "1",
+      "2",
+    ]
+    user_Bob            = []
+    "not an identifier" = {}
+    c                   = false
+  }
+  dd = "z"
+}
+`
+
+	if got := string(c.HCL()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+package terraform
+
+import "strings"
+
+// name makes a Terraform resource name from its parts, replacing any
+// characters Terraform does not allow with underscores.
+func name(parts ...string) string {
+	var b strings.Builder
+
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteByte('_')
+		}
+
+		for _, r := range part {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+				b.WriteRune(r)
+			default:
+				b.WriteByte('_')
+			}
+		}
+	}
+
+	s := b.String()
+
+	if s == "" || s[0] >= '0' && s[0] <= '9' || s[0] == '-' {
+		s = "_" + s
+	}
+
+	return s
+}
@@ -0,0 +1,381 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+// Tags read from users and groups to fill in their identity store attributes.
+const (
+	tagDisplayName = "Display Name"
+	tagFullName    = "Full Name"
+	tagGivenName   = "Given Name"
+	tagFamilyName  = "Family Name"
+	tagEmail       = "Email"
+	tagDescription = "Description"
+)
+
+// Options change how the configuration is synthesized.
+type Options struct {
+	// Compact makes one account assignment resource for each principal and
+	// role, with an instance for each of its accounts, rather than a resource
+	// for every assignment.
+	Compact bool
+}
+
+// Synthesize builds the Terraform configuration for the entities in a model
+// and the assignments made between them. The Identity Center instance is
+// looked up rather than configured, as there is only ever one per
+// organisation.
+//
+// The inline policies of the roles are only included once read by
+// ReadPolicies.
+//
+// Resources are named after the entities they are made for, keeping the names
+// recorded in the addresses by previous runs. The addresses are updated with
+// the names given out, and a moved block is added for each resource of an
+// entity renamed since, or for each assignment when switching between compact
+// and full output.
+func Synthesize(m *identitydsl.Model, assignments []identitydsl.Assignment, addresses *Addresses, opts Options) *Config {
+	c := &Config{}
+	n := newNamer(addresses)
+
+	for _, r := range m.Roles {
+		n.entity("role:"+r.Name, roleValues(r)...)
+	}
+
+	for _, u := range m.Users {
+		n.entity("user:"+u.Name, attributeValues(u.Attributes)...)
+	}
+
+	for _, g := range m.Groups {
+		n.entity("group:"+g.Name, attributeValues(g.Attributes)...)
+	}
+
+	n.detectRenames(addresses.Fingerprints)
+
+	c.Add("data", "aws_ssoadmin_instances", "this")
+
+	locals := c.Add("locals")
+	locals.Body.Set("instance_arn", Ref("tolist(data.aws_ssoadmin_instances.this.arns)[0]"))
+	locals.Body.Set("identity_store_id", Ref("tolist(data.aws_ssoadmin_instances.this.identity_store_ids)[0]"))
+
+	for _, r := range m.Roles {
+		permissionSet(c, n, r)
+	}
+
+	for _, u := range m.Users {
+		user(c, n, u)
+	}
+
+	for _, g := range m.Groups {
+		group(c, n, g)
+	}
+
+	for _, g := range m.Groups {
+		for _, member := range g.Members {
+			membership(c, n, g, member.User)
+		}
+	}
+
+	if opts.Compact {
+		compactAssignments(c, n, locals, assignments)
+	} else {
+		for _, a := range assignments {
+			assignment(c, n, a)
+		}
+	}
+
+	n.save(addresses)
+
+	for _, move := range addresses.Moves {
+		b := c.Add("moved")
+		b.Body.Set("from", Traversal(move.From))
+		b.Body.Set("to", Traversal(move.To))
+	}
+
+	return c
+}
+
+// roleValues lists the policies and properties of a role for its
+// fingerprint. The policy a role defaults to is left out, as it is named
+// after the role and so changes when the role is renamed.
+func roleValues(r *identitydsl.Role) []string {
+	var values []string
+
+	if len(r.Policies) != 1 || string(r.Policies[0]) != r.Name {
+		for _, p := range r.Policies {
+			values = append(values, "policy:"+string(p))
+		}
+	}
+
+	if r.Description != "" {
+		values = append(values, "description:"+r.Description)
+	}
+
+	if r.SessionDuration != 0 {
+		values = append(values, "session:"+identitydsl.FormatSessionDuration(r.SessionDuration))
+	}
+
+	if r.RelayState != "" {
+		values = append(values, "relay:"+r.RelayState)
+	}
+
+	if r.Inline != nil {
+		values = append(values, "inline:"+r.Inline.Path)
+	}
+
+	if r.Boundary != "" {
+		values = append(values, "boundary:"+string(r.Boundary))
+	}
+
+	return values
+}
+
+// attributeValues lists the labels and tags of an entity for its
+// fingerprint.
+func attributeValues(a identitydsl.Attributes) []string {
+	var values []string
+
+	for _, l := range a.Labels {
+		values = append(values, "label:"+string(l))
+	}
+
+	for _, t := range a.Tags {
+		values = append(values, "tag:"+t.Key+"\x00"+t.Value)
+	}
+
+	return values
+}
+
+// permissionSetName returns the name of the permission set for a role.
+func permissionSetName(n *namer, r *identitydsl.Role) string {
+	return n.name("aws_ssoadmin_permission_set", []string{"role:" + r.Name}, r.Name)
+}
+
+// principalRef refers to the ID of the user or group in an assignment.
+func principalRef(n *namer, a identitydsl.Assignment) Ref {
+	if a.User != nil {
+		return Ref("aws_identitystore_user." + userName(n, a.User) + ".user_id")
+	}
+
+	return Ref("aws_identitystore_group." + groupName(n, a.Group) + ".group_id")
+}
+
+func userName(n *namer, u *identitydsl.User) string {
+	return n.name("aws_identitystore_user", []string{"user:" + u.Name}, u.Name)
+}
+
+func groupName(n *namer, g *identitydsl.Group) string {
+	return n.name("aws_identitystore_group", []string{"group:" + g.Name}, g.Name)
+}
+
+func permissionSet(c *Config, n *namer, r *identitydsl.Role) {
+	key := permissionSetName(n, r)
+	arn := Ref("aws_ssoadmin_permission_set." + key + ".arn")
+
+	b := c.Add("resource", "aws_ssoadmin_permission_set", key)
+	b.Body.Set("name", String(r.Name))
+	b.Body.Set("instance_arn", Ref("local.instance_arn"))
+
+	if r.Description != "" {
+		b.Body.Set("description", String(r.Description))
+	}
+
+	if r.SessionDuration != 0 {
+		b.Body.Set("session_duration", String(identitydsl.FormatSessionDuration(r.SessionDuration)))
+	}
+
+	if r.RelayState != "" {
+		b.Body.Set("relay_state", String(r.RelayState))
+	}
+
+	if r.Inline != nil && r.Inline.Document != "" {
+		b := c.Add("resource", "aws_ssoadmin_permission_set_inline_policy", n.name("aws_ssoadmin_permission_set_inline_policy", []string{"role:" + r.Name}, r.Name))
+		b.Body.Set("inline_policy", String(r.Inline.Document))
+		b.Body.Set("instance_arn", Ref("local.instance_arn"))
+		b.Body.Set("permission_set_arn", arn)
+	}
+
+	if r.Boundary != "" {
+		b := c.Add("resource", "aws_ssoadmin_permissions_boundary_attachment", n.name("aws_ssoadmin_permissions_boundary_attachment", []string{"role:" + r.Name}, r.Name))
+		b.Body.Set("instance_arn", Ref("local.instance_arn"))
+		b.Body.Set("permission_set_arn", arn)
+
+		boundary := b.Body.Add("permissions_boundary")
+
+		if r.Boundary.AWSManaged() {
+			boundary.Body.Set("managed_policy_arn", String(r.Boundary))
+		} else {
+			ref := boundary.Body.Add("customer_managed_policy_reference")
+			ref.Body.Set("name", String(r.Boundary.Name()))
+			ref.Body.Set("path", String(r.Boundary.Path()))
+		}
+	}
+
+	for _, p := range r.Policies {
+		key := []string{"role:" + r.Name, "policy:" + string(p)}
+
+		if p.AWSManaged() {
+			b := c.Add("resource", "aws_ssoadmin_managed_policy_attachment", n.name("aws_ssoadmin_managed_policy_attachment", key, r.Name, p.Name()))
+			b.Body.Set("instance_arn", Ref("local.instance_arn"))
+			b.Body.Set("managed_policy_arn", String(p))
+			b.Body.Set("permission_set_arn", arn)
+			continue
+		}
+
+		b := c.Add("resource", "aws_ssoadmin_customer_managed_policy_attachment", n.name("aws_ssoadmin_customer_managed_policy_attachment", key, r.Name, p.Name()))
+		b.Body.Set("instance_arn", Ref("local.instance_arn"))
+		b.Body.Set("permission_set_arn", arn)
+
+		ref := b.Body.Add("customer_managed_policy_reference")
+		ref.Body.Set("name", String(p.Name()))
+		ref.Body.Set("path", String(p.Path()))
+	}
+}
+
+func user(c *Config, n *namer, u *identitydsl.User) {
+	given, family := u.Name, u.Name
+
+	if i := strings.Index(u.Name, "."); i > 0 && i < len(u.Name)-1 {
+		given, family = u.Name[:i], u.Name[i+1:]
+	}
+
+	b := c.Add("resource", "aws_identitystore_user", userName(n, u))
+	b.Body.Set("identity_store_id", Ref("local.identity_store_id"))
+	b.Body.Set("user_name", String(u.Name))
+	b.Body.Set("display_name", String(tag(u.Attributes, u.Name, tagDisplayName, tagFullName)))
+
+	nb := b.Body.Add("name")
+	nb.Body.Set("given_name", String(tag(u.Attributes, given, tagGivenName)))
+	nb.Body.Set("family_name", String(tag(u.Attributes, family, tagFamilyName)))
+
+	if email, ok := u.Tag(tagEmail); ok {
+		e := b.Body.Add("emails")
+		e.Body.Set("value", String(email))
+		e.Body.Set("primary", Bool(true))
+	}
+}
+
+func group(c *Config, n *namer, g *identitydsl.Group) {
+	b := c.Add("resource", "aws_identitystore_group", groupName(n, g))
+	b.Body.Set("identity_store_id", Ref("local.identity_store_id"))
+	b.Body.Set("display_name", String(tag(g.Attributes, g.Name, tagDisplayName)))
+
+	if description, ok := g.Tag(tagDescription); ok {
+		b.Body.Set("description", String(description))
+	}
+}
+
+func membership(c *Config, n *namer, g *identitydsl.Group, u *identitydsl.User) {
+	const typ = "aws_identitystore_group_membership"
+
+	b := c.Add("resource", typ, n.name(typ, []string{"group:" + g.Name, "user:" + u.Name}, g.Name, u.Name))
+	b.Body.Set("identity_store_id", Ref("local.identity_store_id"))
+	b.Body.Set("group_id", Ref("aws_identitystore_group."+groupName(n, g)+".group_id"))
+	b.Body.Set("member_id", Ref("aws_identitystore_user."+userName(n, u)+".user_id"))
+}
+
+// assignmentKey identifies an assignment by the entities it is made between.
+func assignmentKey(a identitydsl.Assignment) []string {
+	return append([]string{"account:" + a.Account.ID}, compactKey(a)...)
+}
+
+// compactKey identifies the principal and role of an assignment, which share
+// a resource in compact output.
+func compactKey(a identitydsl.Assignment) []string {
+	return []string{
+		strings.ToLower(a.PrincipalType()) + ":" + a.PrincipalName(),
+		"role:" + a.Role.Name,
+	}
+}
+
+func compactName(n *namer, a identitydsl.Assignment) string {
+	return n.name(
+		"aws_ssoadmin_account_assignment",
+		compactKey(a),
+		strings.ToLower(a.PrincipalType()), a.PrincipalName(), a.Role.Name,
+	)
+}
+
+func assignment(c *Config, n *namer, a identitydsl.Assignment) {
+	const typ = "aws_ssoadmin_account_assignment"
+
+	key := n.name(
+		typ,
+		assignmentKey(a),
+		a.Account.ID, strings.ToLower(a.PrincipalType()), a.PrincipalName(), a.Role.Name,
+	)
+
+	n.movedFrom(typ, compactKey(a), "["+quoteHCL(a.Account.ID)+"]", typ+"."+key)
+
+	b := c.Add("resource", typ, key)
+	b.Body.Set("instance_arn", Ref("local.instance_arn"))
+	b.Body.Set("permission_set_arn", Ref("aws_ssoadmin_permission_set."+permissionSetName(n, a.Role)+".arn"))
+	b.Body.Set("principal_id", principalRef(n, a))
+	b.Body.Set("principal_type", String(a.PrincipalType()))
+	b.Body.Set("target_id", String(a.Account.ID))
+	b.Body.Set("target_type", String("AWS_ACCOUNT"))
+}
+
+// compactAssignments adds a resource for each principal and role, with an
+// instance for each account listed for it in the assignment_accounts local.
+func compactAssignments(c *Config, n *namer, locals *Block, assignments []identitydsl.Assignment) {
+	const typ = "aws_ssoadmin_account_assignment"
+
+	var keys []string
+
+	accounts := map[string]List{}
+	first := map[string]identitydsl.Assignment{}
+	seen := map[string]bool{}
+
+	for _, a := range assignments {
+		key := compactName(n, a)
+
+		if _, ok := first[key]; !ok {
+			keys = append(keys, key)
+			first[key] = a
+		}
+
+		if id := key + "\x00" + a.Account.ID; !seen[id] {
+			seen[id] = true
+			accounts[key] = append(accounts[key], String(a.Account.ID))
+
+			n.movedFrom(typ, assignmentKey(a), "", typ+"."+key+"["+quoteHCL(a.Account.ID)+"]")
+		}
+	}
+
+	var local Object
+
+	for _, key := range keys {
+		a := first[key]
+
+		local = append(local, Attribute{key, accounts[key]})
+
+		b := c.Add("resource", typ, key)
+		b.Body.Set("for_each", Ref("toset(local.assignment_accounts."+key+")"))
+		b.Body.Set("instance_arn", Ref("local.instance_arn"))
+		b.Body.Set("permission_set_arn", Ref("aws_ssoadmin_permission_set."+permissionSetName(n, a.Role)+".arn"))
+		b.Body.Set("principal_id", principalRef(n, a))
+		b.Body.Set("principal_type", String(a.PrincipalType()))
+		b.Body.Set("target_id", Ref("each.value"))
+		b.Body.Set("target_type", String("AWS_ACCOUNT"))
+	}
+
+	if len(local) > 0 {
+		locals.Body.Set("assignment_accounts", local)
+	}
+}
+
+// tag returns the value of the first of the tags the entity has, or the
+// fallback when it has none of them.
+func tag(a identitydsl.Attributes, fallback string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := a.Tag(key); ok {
+			return value
+		}
+	}
+
+	return fallback
+}
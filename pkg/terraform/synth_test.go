@@ -0,0 +1,301 @@
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xdesign-jheather/identitydsl/pkg/identitydsl"
+)
+
+// synthesize parses the input and decodes the JSON synthesized from it.
+func synthesize(t *testing.T, opts Options, input string) map[string]interface{} {
+	t.Helper()
+
+	doc, err := identitydsl.Parse(input)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assignments, err := identitydsl.Expand(doc)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := Synthesize(identitydsl.NewModel(doc), assignments, &Addresses{}, opts).JSON()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out map[string]interface{}
+
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	return out
+}
+
+// resource digs out a resource from decoded JSON.
+func resource(out map[string]interface{}, typ, name string) map[string]interface{} {
+	r, _ := out["resource"].(map[string]interface{})[typ].(map[string]interface{})[name].(map[string]interface{})
+	return r
+}
+
+func TestSynthesize(t *testing.T) {
+	out := synthesize(t, Options{}, `Account 123456789012
+	Production
+
+User Bob.Smith
+	Email bob@example.com
+	"Full Name" "Bob Smith"
+
+Group DBA
+	Description "Database administrators"
+	Members Bob.Smith
+
+Role ReadOnly
+	Description "Read only access, for everyone"
+	SessionDuration 1h30m
+	RelayState https://console.aws.amazon.com/s3/home?region=eu-west-1
+	Boundary arn:aws:iam::aws:policy/PowerUserAccess
+Role FullAccess
+	Custom
+	Boundary /security/Boundary
+
+Assign
+	Account Production
+	Role ReadOnly, FullAccess
+	Group DBA
+	User Bob.Smith`)
+
+	for _, test := range []struct {
+		typ, name string
+		want      map[string]interface{}
+	}{
+		{
+			"aws_ssoadmin_permission_set", "ReadOnly",
+			map[string]interface{}{
+				"name":             "ReadOnly",
+				"instance_arn":     "${local.instance_arn}",
+				"description":      "Read only access, for everyone",
+				"session_duration": "PT1H30M",
+				"relay_state":      "https://console.aws.amazon.com/s3/home?region=eu-west-1",
+			},
+		},
+		{
+			"aws_ssoadmin_permission_set", "FullAccess",
+			map[string]interface{}{
+				"name":         "FullAccess",
+				"instance_arn": "${local.instance_arn}",
+			},
+		},
+		{
+			"aws_ssoadmin_permissions_boundary_attachment", "ReadOnly",
+			map[string]interface{}{
+				"instance_arn":       "${local.instance_arn}",
+				"permission_set_arn": "${aws_ssoadmin_permission_set.ReadOnly.arn}",
+				"permissions_boundary": map[string]interface{}{
+					"managed_policy_arn": "arn:aws:iam::aws:policy/PowerUserAccess",
+				},
+			},
+		},
+		{
+			"aws_ssoadmin_permissions_boundary_attachment", "FullAccess",
+			map[string]interface{}{
+				"instance_arn":       "${local.instance_arn}",
+				"permission_set_arn": "${aws_ssoadmin_permission_set.FullAccess.arn}",
+				"permissions_boundary": map[string]interface{}{
+					"customer_managed_policy_reference": map[string]interface{}{
+						"name": "Boundary",
+						"path": "/security/",
+					},
+				},
+			},
+		},
+		{
+			"aws_ssoadmin_customer_managed_policy_attachment", "ReadOnly_ReadOnly",
+			map[string]interface{}{
+				"instance_arn":       "${local.instance_arn}",
+				"permission_set_arn": "${aws_ssoadmin_permission_set.ReadOnly.arn}",
+				"customer_managed_policy_reference": map[string]interface{}{
+					"name": "ReadOnly",
+					"path": "/",
+				},
+			},
+		},
+		{
+			"aws_ssoadmin_customer_managed_policy_attachment", "FullAccess_Custom",
+			map[string]interface{}{
+				"instance_arn":       "${local.instance_arn}",
+				"permission_set_arn": "${aws_ssoadmin_permission_set.FullAccess.arn}",
+				"customer_managed_policy_reference": map[string]interface{}{
+					"name": "Custom",
+					"path": "/",
+				},
+			},
+		},
+		{
+			"aws_identitystore_user", "Bob_Smith",
+			map[string]interface{}{
+				"identity_store_id": "${local.identity_store_id}",
+				"user_name":         "Bob.Smith",
+				"display_name":      "Bob Smith",
+				"name": map[string]interface{}{
+					"given_name":  "Bob",
+					"family_name": "Smith",
+				},
+				"emails": map[string]interface{}{
+					"value":   "bob@example.com",
+					"primary": true,
+				},
+			},
+		},
+		{
+			"aws_identitystore_group", "DBA",
+			map[string]interface{}{
+				"identity_store_id": "${local.identity_store_id}",
+				"display_name":      "DBA",
+				"description":       "Database administrators",
+			},
+		},
+		{
+			"aws_identitystore_group_membership", "DBA_Bob_Smith",
+			map[string]interface{}{
+				"identity_store_id": "${local.identity_store_id}",
+				"group_id":          "${aws_identitystore_group.DBA.group_id}",
+				"member_id":         "${aws_identitystore_user.Bob_Smith.user_id}",
+			},
+		},
+		{
+			"aws_ssoadmin_account_assignment", "_123456789012_group_DBA_ReadOnly",
+			map[string]interface{}{
+				"instance_arn":       "${local.instance_arn}",
+				"permission_set_arn": "${aws_ssoadmin_permission_set.ReadOnly.arn}",
+				"principal_id":       "${aws_identitystore_group.DBA.group_id}",
+				"principal_type":     "GROUP",
+				"target_id":          "123456789012",
+				"target_type":        "AWS_ACCOUNT",
+			},
+		},
+		{
+			"aws_ssoadmin_account_assignment", "_123456789012_user_Bob_Smith_FullAccess",
+			map[string]interface{}{
+				"instance_arn":       "${local.instance_arn}",
+				"permission_set_arn": "${aws_ssoadmin_permission_set.FullAccess.arn}",
+				"principal_id":       "${aws_identitystore_user.Bob_Smith.user_id}",
+				"principal_type":     "USER",
+				"target_id":          "123456789012",
+				"target_type":        "AWS_ACCOUNT",
+			},
+		},
+	} {
+		if got := resource(out, test.typ, test.name); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s.%s:\ngot  %v\nwant %v", test.typ, test.name, got, test.want)
+		}
+	}
+
+	if got := len(out["resource"].(map[string]interface{})["aws_ssoadmin_account_assignment"].(map[string]interface{})); got != 4 {
+		t.Errorf("got %d account assignments, want 4", got)
+	}
+}
+
+func TestSynthesizeCompact(t *testing.T) {
+	out := synthesize(t, Options{Compact: true}, `Account 123456789012, 098765432109
+	Production
+
+Group DBA
+Role ReadOnly
+
+Assign
+	Account Production
+	Role ReadOnly
+	Group DBA
+
+Assign
+	Account 123456789012
+	Role ReadOnly
+	Group DBA`)
+
+	locals := out["locals"].(map[string]interface{})
+
+	want := map[string]interface{}{
+		"group_DBA_ReadOnly": []interface{}{"123456789012", "098765432109"},
+	}
+
+	if got := locals["assignment_accounts"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got accounts %v, want %v", got, want)
+	}
+
+	assignments := out["resource"].(map[string]interface{})["aws_ssoadmin_account_assignment"].(map[string]interface{})
+
+	if len(assignments) != 1 {
+		t.Errorf("got %d account assignments, want 1", len(assignments))
+	}
+
+	wantResource := map[string]interface{}{
+		"for_each":           "${toset(local.assignment_accounts.group_DBA_ReadOnly)}",
+		"instance_arn":       "${local.instance_arn}",
+		"permission_set_arn": "${aws_ssoadmin_permission_set.ReadOnly.arn}",
+		"principal_id":       "${aws_identitystore_group.DBA.group_id}",
+		"principal_type":     "GROUP",
+		"target_id":          "${each.value}",
+		"target_type":        "AWS_ACCOUNT",
+	}
+
+	if got := resource(out, "aws_ssoadmin_account_assignment", "group_DBA_ReadOnly"); !reflect.DeepEqual(got, wantResource) {
+		t.Errorf("got:\n%v\nwant:\n%v", got, wantResource)
+	}
+}
+
+func TestSynthesizeInlinePolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	policy := `{
+	"Version": "2012-10-17",
+	"Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]
+}`
+
+	if err := os.WriteFile(filepath.Join(dir, "extra.json"), []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := identitydsl.LoadSource(filepath.Join(dir, "main.idsl"), "Role ReadOnly\n\tInline extra.json")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := identitydsl.NewModel(doc)
+
+	if err := m.ReadPolicies(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := Synthesize(m, nil, &Addresses{}, Options{}).JSON()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out map[string]interface{}
+
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"inline_policy":      `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+		"instance_arn":       "${local.instance_arn}",
+		"permission_set_arn": "${aws_ssoadmin_permission_set.ReadOnly.arn}",
+	}
+
+	if got := resource(out, "aws_ssoadmin_permission_set_inline_policy", "ReadOnly"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}